@@ -36,6 +36,7 @@ type collector struct {
 	timeout     time.Duration
 	enableTLS   bool
 	insecureTLS bool
+	observer    StateObserver
 }
 
 // NewCollector creates a collector instance
@@ -115,6 +116,7 @@ func (c *collector) prepare() error {
 				d.Address = strings.TrimRight(s.Target, ".")
 				d.User = dev.User
 				d.Password = dev.Password
+				d.Group = dev.Group
 				if err := c.getIdentity(d); err != nil {
 					return err
 				}
@@ -161,6 +163,10 @@ func (c *collector) collectForDevice(d *config.Device, ch chan<- prometheus.Metr
 }
 
 func (c *collector) connectAndCollect(d *config.Device, ch chan<- prometheus.Metric) error {
+	// /metrics and the push outputs may gather at the same time
+	d.Collecting.Lock()
+	defer d.Collecting.Unlock()
+
 	cl, err := c.connect(d)
 	if err != nil {
 		log.WithFields(log.Fields{
@@ -179,7 +185,7 @@ func (c *collector) connectAndCollect(d *config.Device, ch chan<- prometheus.Met
 	}()
 
 	for _, co := range c.collectors {
-		ctx := &Context{ch, d, cl}
+		ctx := &Context{ch, d, cl, c.observer}
 		err = co.Collect(ctx)
 		if err != nil {
 			return err
@@ -262,6 +268,8 @@ func (c *collector) connect(d *config.Device) (*routeros.Client, error) {
 	log.WithField("device", d.Name).Debug("done with login")
 
 	d.Cli = client
+	// the device may have been upgraded while disconnected
+	d.Version = ""
 
 	return client, nil
 }
@@ -0,0 +1,67 @@
+package collector
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/routeros.v2"
+
+	"mikrotik-exporter/internal/config"
+)
+
+// concurrencyCollector records how many scrapes run at the same time
+type concurrencyCollector struct {
+	running int32
+	max     int32
+}
+
+func (c *concurrencyCollector) Describe(ch chan<- *prometheus.Desc) {
+}
+
+func (c *concurrencyCollector) Collect(ctx *Context) error {
+	n := atomic.AddInt32(&c.running, 1)
+	defer atomic.AddInt32(&c.running, -1)
+
+	for {
+		max := atomic.LoadInt32(&c.max)
+		if n <= max || atomic.CompareAndSwapInt32(&c.max, max, n) {
+			break
+		}
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	return nil
+}
+
+func TestConcurrentGatherSerializesDevice(t *testing.T) {
+	// a connected client is reused, so no device is dialed
+	d := &config.Device{Name: "test", Address: "192.168.1.1", Cli: &routeros.Client{}}
+	co := &concurrencyCollector{}
+
+	c := &collector{
+		devices:    []*config.Device{d},
+		collectors: []Collector{co},
+	}
+
+	registry := prometheus.NewRegistry()
+	if !assert.NoError(t, registry.Register(c)) {
+		return
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			defer wg.Done()
+			_, err := registry.Gather()
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&co.max))
+}
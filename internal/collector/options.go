@@ -30,3 +30,10 @@ func WithTLS(insecure bool) Option {
 		c.insecureTLS = insecure
 	}
 }
+
+// WithStateObserver notifies o about the state of objects on every poll
+func WithStateObserver(o StateObserver) Option {
+	return func(c *collector) {
+		c.observer = o
+	}
+}
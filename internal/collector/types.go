@@ -1,19 +1,80 @@
 package collector
 
 import (
+	"fmt"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"gopkg.in/routeros.v2"
 
 	"mikrotik-exporter/internal/config"
+	"mikrotik-exporter/internal/helper"
 )
 
 type Context struct {
-	Ch     chan<- prometheus.Metric
-	Device *config.Device
-	Client *routeros.Client
+	Ch       chan<- prometheus.Metric
+	Device   *config.Device
+	Client   *routeros.Client
+	Observer StateObserver
+}
+
+// ObserveState reports the current state of an object, e.g. a BGP session, to the state observer
+func (ctx *Context) ObserveState(kind, object, state string) {
+	if ctx.Observer != nil {
+		ctx.Observer.ObserveState(ctx.Device.Name, kind, object, state)
+	}
 }
 
 type Collector interface {
 	Describe(ch chan<- *prometheus.Desc)
 	Collect(ctx *Context) error
 }
+
+// MajorVersion returns the major RouterOS version of the device, e.g. 6 or 7.
+// The version is fetched once per connection.
+func (ctx *Context) MajorVersion() (int, error) {
+	version, err := ctx.version()
+	if err != nil {
+		return 0, err
+	}
+
+	return helper.ParseMajorVersion(version)
+}
+
+// MinorVersion returns the minor RouterOS version of the device, e.g. 13 for 7.13.2
+func (ctx *Context) MinorVersion() (int, error) {
+	version, err := ctx.version()
+	if err != nil {
+		return 0, err
+	}
+
+	return helper.ParseMinorVersion(version)
+}
+
+func (ctx *Context) version() (string, error) {
+	ctx.Device.Lock()
+	version := ctx.Device.Version
+	ctx.Device.Unlock()
+
+	if version == "" {
+		reply, err := ctx.Client.Run("/system/resource/print", "=.proplist=version")
+		if err != nil {
+			return "", err
+		}
+		if len(reply.Re) == 0 {
+			return "", fmt.Errorf("no version reported by device")
+		}
+
+		version = reply.Re[0].Map["version"]
+
+		ctx.Device.Lock()
+		ctx.Device.Version = version
+		ctx.Device.Unlock()
+	}
+
+	return version, nil
+}
+
+// StateObserver is notified about the state of objects on every poll
+type StateObserver interface {
+	ObserveState(device, kind, object, state string)
+}
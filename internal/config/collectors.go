@@ -0,0 +1,110 @@
+package config
+
+import "time"
+
+// Collectors represents the settings of collectors which can be tuned
+type Collectors struct {
+	BGPv7         BGPSession    `yaml:"bgpv7,omitempty"`
+	Firewall      Firewall      `yaml:"firewall,omitempty"`
+	AddressList   AddressList   `yaml:"address_list,omitempty"`
+	PPP           PPP           `yaml:"ppp,omitempty"`
+	WireGuard     WireGuard     `yaml:"wireguard,omitempty"`
+	ARP           ARP           `yaml:"arp,omitempty"`
+	Firmware      Firmware      `yaml:"firmware,omitempty"`
+	CPU           CPU           `yaml:"cpu,omitempty"`
+	Hotspot       Hotspot       `yaml:"hotspot,omitempty"`
+	Ping          Ping          `yaml:"ping,omitempty"`
+	InterfaceRate InterfaceRate `yaml:"interface_rate,omitempty"`
+}
+
+// BGPSession represents the settings of the bgpv7 collector
+type BGPSession struct {
+	PrefixesAccepted bool `yaml:"prefixes_accepted,omitempty"`
+}
+
+// Firewall represents the settings of the firewall collector
+type Firewall struct {
+	CommentRegex string `yaml:"comment_regex,omitempty"`
+}
+
+// AddressList represents the settings of the address_list collector
+type AddressList struct {
+	Lists     []string `yaml:"lists,omitempty"`
+	IPv6Lists []string `yaml:"ipv6_lists,omitempty"`
+}
+
+// PPP represents the settings of the ppp collector
+type PPP struct {
+	Users    []string `yaml:"users,omitempty"`
+	MaxUsers int      `yaml:"max_users,omitempty"`
+}
+
+// WireGuard represents the settings of the wireguard collector
+type WireGuard struct {
+	HandshakeThreshold time.Duration `yaml:"handshake_threshold,omitempty"`
+}
+
+// ARP represents the settings of the arp collector
+type ARP struct {
+	Detailed bool `yaml:"detailed,omitempty"`
+}
+
+// Firmware represents the settings of the firmware collector
+type Firmware struct {
+	UpdateCheck         bool          `yaml:"update_check,omitempty"`
+	UpdateCheckInterval time.Duration `yaml:"update_check_interval,omitempty"`
+}
+
+// CPU represents the settings of the cpu collector
+type CPU struct {
+	Profile         bool          `yaml:"profile,omitempty"`
+	ProfileDuration time.Duration `yaml:"profile_duration,omitempty"`
+}
+
+// Hotspot represents the settings of the hotspot collector
+type Hotspot struct {
+	MaxUsers int `yaml:"max_users,omitempty"`
+}
+
+// Ping represents the settings of the ping collector
+type Ping struct {
+	Count      int           `yaml:"count,omitempty"`
+	Size       int           `yaml:"size,omitempty"`
+	Interval   time.Duration `yaml:"interval,omitempty"`
+	Traceroute bool          `yaml:"traceroute,omitempty"`
+	Targets    []*PingTarget `yaml:"targets,omitempty"`
+}
+
+// PingTarget represents a target pinged from the devices and groups listed, or from all devices if none are listed
+type PingTarget struct {
+	Name    string   `yaml:"name,omitempty"`
+	Address string   `yaml:"address"`
+	Devices []string `yaml:"devices,omitempty"`
+	Groups  []string `yaml:"groups,omitempty"`
+}
+
+// AppliesTo returns whether the target is pinged from the device
+func (t *PingTarget) AppliesTo(d *Device) bool {
+	if len(t.Devices) == 0 && len(t.Groups) == 0 {
+		return true
+	}
+
+	for _, name := range t.Devices {
+		if name == d.Name {
+			return true
+		}
+	}
+
+	for _, group := range t.Groups {
+		if d.Group != "" && group == d.Group {
+			return true
+		}
+	}
+
+	return false
+}
+
+// InterfaceRate represents the settings of the interface_rate collector
+type InterfaceRate struct {
+	NameRegex string `yaml:"name_regex,omitempty"`
+}
@@ -11,8 +11,10 @@ import (
 
 // Config represents the configuration for the exporter
 type Config struct {
-	Devices  []*Device       `yaml:"devices"`
-	Features map[string]bool `yaml:"features,omitempty"`
+	Devices    []*Device       `yaml:"devices"`
+	Features   map[string]bool `yaml:"features,omitempty"`
+	Collectors Collectors      `yaml:"collectors,omitempty"`
+	Outputs    Outputs         `yaml:"outputs,omitempty"`
 }
 
 // Device represents a target device
@@ -24,7 +26,11 @@ type Device struct {
 	User     string           `yaml:"user"`
 	Password string           `yaml:"password"`
 	Port     string           `yaml:"port"`
+	Group    string           `yaml:"group,omitempty"`
 	Cli      *routeros.Client `yaml:"-"`
+	Version  string           `yaml:"-"`
+	// Collecting serializes scrapes of the device, the client is not safe for concurrent use
+	Collecting sync.Mutex `yaml:"-"`
 }
 
 type SrvRecord struct {
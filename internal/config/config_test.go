@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io/ioutil"
 	"testing"
+	"time"
 )
 
 func TestShouldParse(t *testing.T) {
@@ -31,6 +32,51 @@ func TestShouldParse(t *testing.T) {
 	assertFeature("Ipsec", getFeature(c, "ipsec"), t)
 	assertFeature("Lte", getFeature(c, "lte"), t)
 	assertFeature("Netwatch", getFeature(c, "netwatch"), t)
+	assertFeature("Firewall", getFeature(c, "firewall"), t)
+
+	if c.Collectors.Firewall.CommentRegex != "^export" {
+		t.Fatalf("expected firewall comment regex %q, got %q", "^export", c.Collectors.Firewall.CommentRegex)
+	}
+
+	if c.Collectors.Ping.Count != 5 || len(c.Collectors.Ping.Targets) != 2 {
+		t.Fatalf("expected ping count 5 and 2 targets, got %+v", c.Collectors.Ping)
+	}
+
+	if c.Devices[1].Group != "branch" {
+		t.Fatalf("expected group branch for device test2, got %q", c.Devices[1].Group)
+	}
+
+	if c.Outputs.Interval != time.Minute {
+		t.Fatalf("expected outputs interval %v, got %v", time.Minute, c.Outputs.Interval)
+	}
+
+	if c.Outputs.OTLP == nil || c.Outputs.OTLP.Endpoint != "otel-collector:4317" || !c.Outputs.OTLP.Insecure {
+		t.Fatalf("expected otlp output to be configured, got %+v", c.Outputs.OTLP)
+	}
+}
+
+func TestPingTargetAppliesTo(t *testing.T) {
+	router := &Device{Name: "router"}
+	branch := &Device{Name: "branch1", Group: "branch"}
+
+	testCases := []struct {
+		target   *PingTarget
+		device   *Device
+		expected bool
+	}{
+		{&PingTarget{Address: "1.1.1.1"}, router, true},
+		{&PingTarget{Address: "1.1.1.1", Devices: []string{"router"}}, router, true},
+		{&PingTarget{Address: "1.1.1.1", Devices: []string{"router"}}, branch, false},
+		{&PingTarget{Address: "1.1.1.1", Groups: []string{"branch"}}, branch, true},
+		{&PingTarget{Address: "1.1.1.1", Groups: []string{"branch"}}, router, false},
+		{&PingTarget{Address: "1.1.1.1", Devices: []string{"router"}, Groups: []string{"branch"}}, branch, true},
+	}
+
+	for _, testCase := range testCases {
+		if got := testCase.target.AppliesTo(testCase.device); got != testCase.expected {
+			t.Errorf("expected %v for target %+v and device %s, got %v", testCase.expected, testCase.target, testCase.device.Name, got)
+		}
+	}
 }
 
 func loadTestFile(t *testing.T) []byte {
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"time"
+)
+
+// Outputs represents the sinks metrics are pushed to after polling the devices
+type Outputs struct {
+	Interval    time.Duration `yaml:"interval,omitempty"`
+	OTLP        *OTLP         `yaml:"otlp,omitempty"`
+	InfluxDB    *InfluxDB     `yaml:"influxdb,omitempty"`
+	RemoteWrite *RemoteWrite  `yaml:"remote_write,omitempty"`
+	MQTT        *MQTT         `yaml:"mqtt,omitempty"`
+	Webhook     *Webhook      `yaml:"webhook,omitempty"`
+}
+
+// OTLP represents an OpenTelemetry receiver accepting metrics via OTLP/gRPC or OTLP/HTTP
+type OTLP struct {
+	Endpoint string            `yaml:"endpoint"`
+	Protocol string            `yaml:"protocol,omitempty"`
+	Insecure bool              `yaml:"insecure,omitempty"`
+	Headers  map[string]string `yaml:"headers,omitempty"`
+	Timeout  time.Duration     `yaml:"timeout,omitempty"`
+}
+
+// InfluxDB represents an InfluxDB v2 server accepting line protocol writes
+type InfluxDB struct {
+	URL          string        `yaml:"url"`
+	Org          string        `yaml:"org"`
+	Bucket       string        `yaml:"bucket"`
+	Token        string        `yaml:"token"`
+	Insecure     bool          `yaml:"insecure,omitempty"`
+	BatchSize    int           `yaml:"batch_size,omitempty"`
+	Retries      int           `yaml:"retries,omitempty"`
+	RetryBackoff time.Duration `yaml:"retry_backoff,omitempty"`
+	Timeout      time.Duration `yaml:"timeout,omitempty"`
+}
+
+// RemoteWrite represents a receiver of the Prometheus remote_write protocol
+type RemoteWrite struct {
+	URL            string            `yaml:"url"`
+	BasicAuth      *BasicAuth        `yaml:"basic_auth,omitempty"`
+	BearerToken    string            `yaml:"bearer_token,omitempty"`
+	Headers        map[string]string `yaml:"headers,omitempty"`
+	ExternalLabels map[string]string `yaml:"external_labels,omitempty"`
+	Insecure       bool              `yaml:"insecure,omitempty"`
+	Retries        int               `yaml:"retries,omitempty"`
+	RetryBackoff   time.Duration     `yaml:"retry_backoff,omitempty"`
+	Timeout        time.Duration     `yaml:"timeout,omitempty"`
+	BufferDir      string            `yaml:"buffer_dir,omitempty"`
+	BufferMaxFiles int               `yaml:"buffer_max_files,omitempty"`
+}
+
+// BasicAuth represents credentials for HTTP basic authentication
+type BasicAuth struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// MQTT represents a MQTT broker device state is published to
+type MQTT struct {
+	Broker      string        `yaml:"broker"`
+	ClientID    string        `yaml:"client_id,omitempty"`
+	Username    string        `yaml:"username,omitempty"`
+	Password    string        `yaml:"password,omitempty"`
+	TopicPrefix string        `yaml:"topic_prefix,omitempty"`
+	QoS         byte          `yaml:"qos,omitempty"`
+	Retain      bool          `yaml:"retain,omitempty"`
+	TLS         *TLS          `yaml:"tls,omitempty"`
+	Timeout     time.Duration `yaml:"timeout,omitempty"`
+}
+
+// TLS represents the TLS settings used to connect to a server
+type TLS struct {
+	CAFile   string `yaml:"ca_file,omitempty"`
+	CertFile string `yaml:"cert_file,omitempty"`
+	KeyFile  string `yaml:"key_file,omitempty"`
+	Insecure bool   `yaml:"insecure,omitempty"`
+}
+
+// Webhook represents an HTTP endpoint notified about state changes of devices
+type Webhook struct {
+	URL          string            `yaml:"url"`
+	BasicAuth    *BasicAuth        `yaml:"basic_auth,omitempty"`
+	BearerToken  string            `yaml:"bearer_token,omitempty"`
+	Headers      map[string]string `yaml:"headers,omitempty"`
+	Kinds        []string          `yaml:"kinds,omitempty"`
+	Insecure     bool              `yaml:"insecure,omitempty"`
+	Retries      int               `yaml:"retries,omitempty"`
+	RetryBackoff time.Duration     `yaml:"retry_backoff,omitempty"`
+	Timeout      time.Duration     `yaml:"timeout,omitempty"`
+}
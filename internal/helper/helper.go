@@ -2,12 +2,52 @@ package helper
 
 import (
 	"strings"
+	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 const namespace = "mikrotik"
 
+// prefixes remembers the prefixes descriptions were built with, so metric names can be split up again
+var prefixes = struct {
+	sync.RWMutex
+	m map[string]struct{}
+}{m: make(map[string]struct{})}
+
+func registerPrefix(prefix string) {
+	prefixes.Lock()
+	defer prefixes.Unlock()
+
+	prefixes.m[prefix] = struct{}{}
+}
+
+// SplitName splits a fully qualified metric name into the prefix of its collector and the
+// remaining name, e.g. mikrotik_wlan_station_tx_bytes into wlan_station and tx_bytes.
+// Names built with an unknown prefix are split at their first underscore.
+func SplitName(fqName string) (prefix, name string) {
+	s := strings.TrimPrefix(fqName, namespace+"_")
+
+	prefixes.RLock()
+	defer prefixes.RUnlock()
+
+	for p := range prefixes.m {
+		if len(p) > len(prefix) && strings.HasPrefix(s, p+"_") {
+			prefix = p
+		}
+	}
+
+	if prefix != "" {
+		return prefix, s[len(prefix)+1:]
+	}
+
+	if i := strings.Index(s, "_"); i > 0 {
+		return s[:i], s[i+1:]
+	}
+
+	return s, s
+}
+
 func metricStringCleanup(in string) string {
 	return strings.Replace(in, "-", "_", -1)
 }
@@ -17,6 +57,8 @@ func DescriptionForPropertyName(prefix, property string, labelNames []string) *p
 }
 
 func DescriptionForPropertyNameHelpText(prefix, property string, labelNames []string, helpText string) *prometheus.Desc {
+	registerPrefix(prefix)
+
 	return prometheus.NewDesc(
 		prometheus.BuildFQName(namespace, prefix, metricStringCleanup(property)),
 		helpText,
@@ -26,6 +68,8 @@ func DescriptionForPropertyNameHelpText(prefix, property string, labelNames []st
 }
 
 func Description(prefix, name, helpText string, labelNames []string) *prometheus.Desc {
+	registerPrefix(prefix)
+
 	return prometheus.NewDesc(
 		prometheus.BuildFQName(namespace, prefix, name),
 		helpText,
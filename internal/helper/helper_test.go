@@ -0,0 +1,30 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitName(t *testing.T) {
+	Description("wlan_station", "tx_bytes", "", nil)
+	Description("wlan", "clients", "", nil)
+	DescriptionForPropertyName("interface", "rx-byte", nil)
+
+	var testCases = []struct {
+		input  string
+		prefix string
+		name   string
+	}{
+		{"mikrotik_wlan_station_tx_bytes", "wlan_station", "tx_bytes"},
+		{"mikrotik_wlan_clients", "wlan", "clients"},
+		{"mikrotik_interface_rx_byte", "interface", "rx_byte"},
+		{"mikrotik_unknown_some_value", "unknown", "some_value"},
+	}
+
+	for _, testCase := range testCases {
+		prefix, name := SplitName(testCase.input)
+		assert.Equal(t, testCase.prefix, prefix)
+		assert.Equal(t, testCase.name, name)
+	}
+}
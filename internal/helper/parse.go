@@ -14,6 +14,9 @@ import (
 var durationRegex = regexp.MustCompile(`(?:(\d*)w)?(?:(\d*)d)?(?:(\d*)h)?(?:(\d*)m)?(?:(\d*)s)?`)
 var durationParts = [5]time.Duration{time.Hour * 168, time.Hour * 24, time.Hour, time.Minute, time.Second}
 
+// RouterOS 7 appends milliseconds to durations, e.g. 4s70ms, which durationRegex would take for minutes
+var durationMillisRegex = regexp.MustCompile(`(\d+)ms$`)
+
 func SplitStringToFloats(metric string) (float64, float64, error) {
 	strs := strings.Split(metric, ",")
 	if len(strs) == 0 {
@@ -33,6 +36,15 @@ func SplitStringToFloats(metric string) (float64, float64, error) {
 func ParseDuration(duration string) (float64, error) {
 	var u time.Duration
 
+	if match := durationMillisRegex.FindStringSubmatch(duration); match != nil {
+		v, err := strconv.Atoi(match[1])
+		if err != nil {
+			return float64(0), err
+		}
+		u += time.Duration(v) * time.Millisecond
+		duration = strings.TrimSuffix(duration, match[0])
+	}
+
 	reMatch := durationRegex.FindAllStringSubmatch(duration, -1)
 
 	// should get one and only one match back on the regex
@@ -56,3 +68,144 @@ func ParseDuration(duration string) (float64, error) {
 	}
 	return u.Seconds(), nil
 }
+
+// ParseRTT parses round trip times like 12.34ms or 1ms234us, which other durations do not use
+func ParseRTT(rtt string) (float64, error) {
+	if d, err := time.ParseDuration(rtt); err == nil {
+		return d.Seconds(), nil
+	}
+
+	return ParseDuration(rtt)
+}
+
+// ParseMajorVersion returns the major version of RouterOS version strings like "6.49.7 (long-term)" or "7.1beta4"
+func ParseMajorVersion(version string) (int, error) {
+	i := strings.IndexFunc(version, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if i == -1 {
+		i = len(version)
+	}
+
+	if i == 0 {
+		return 0, fmt.Errorf("invalid version %q", version)
+	}
+
+	return strconv.Atoi(version[:i])
+}
+
+// ParseMinorVersion returns the minor version of RouterOS version strings like "7.13.2 (stable)",
+// versions without minor version like "7" or "7rc1" are treated as minor version 0
+func ParseMinorVersion(version string) (int, error) {
+	if _, err := ParseMajorVersion(version); err != nil {
+		return 0, err
+	}
+
+	i := strings.IndexFunc(version, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if i == -1 || version[i] != '.' {
+		return 0, nil
+	}
+
+	minor := version[i+1:]
+	j := strings.IndexFunc(minor, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if j == -1 {
+		j = len(minor)
+	}
+	if j == 0 {
+		return 0, fmt.Errorf("invalid version %q", version)
+	}
+
+	return strconv.Atoi(minor[:j])
+}
+
+var wirelessRateRegex = regexp.MustCompile(`^(\d+(?:\.\d+)?)([kMG]?)bps`)
+
+// ParseWirelessRate parses the rates of wireless stations like "144.4Mbps-20MHz/2S/SGI" into bits per second
+func ParseWirelessRate(rate string) (float64, error) {
+	match := wirelessRateRegex.FindStringSubmatch(rate)
+	if match == nil {
+		return math.NaN(), fmt.Errorf("invalid wireless rate %q", rate)
+	}
+
+	return ParseRate(match[1] + match[2])
+}
+
+// RouterOS 6 prints dates like jan/02/2006, RouterOS 7 like 2006-01-02
+var dateLayouts = []string{"Jan/02/2006 15:04:05", "2006-01-02 15:04:05", "Jan/02/2006", "2006-01-02"}
+
+// ParseDate parses dates in the formats of RouterOS 6 and 7. RouterOS prints dates in the
+// time zone of the device which is not known here, so they are parsed as UTC.
+func ParseDate(date string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, date); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date %q", date)
+}
+
+var sizeMultipliers = map[string]float64{"KiB": 1 << 10, "MiB": 1 << 20, "GiB": 1 << 30}
+
+// ParseSize parses sizes with a binary unit like 2048KiB into bytes, RouterOS configures
+// sizes in KiB so a number without unit is taken as KiB
+func ParseSize(size string) (float64, error) {
+	multiplier := sizeMultipliers["KiB"]
+	for unit, m := range sizeMultipliers {
+		if strings.HasSuffix(size, unit) {
+			multiplier = m
+			size = strings.TrimSuffix(size, unit)
+			break
+		}
+	}
+
+	v, err := strconv.ParseFloat(size, 64)
+	if err != nil {
+		return math.NaN(), err
+	}
+
+	return v * multiplier, nil
+}
+
+var rateMultipliers = map[byte]float64{'k': 1e3, 'K': 1e3, 'M': 1e6, 'G': 1e9}
+
+// ParseRate parses numbers RouterOS may print with a k, M or G suffix like 10M
+func ParseRate(rate string) (float64, error) {
+	multiplier := 1.0
+	if rate != "" {
+		if m, ok := rateMultipliers[rate[len(rate)-1]]; ok {
+			multiplier = m
+			rate = rate[:len(rate)-1]
+		}
+	}
+
+	v, err := strconv.ParseFloat(rate, 64)
+	if err != nil {
+		return math.NaN(), err
+	}
+
+	return v * multiplier, nil
+}
+
+// SplitUploadDownload parses the upload/download value pairs of simple queues like 1000/2M
+func SplitUploadDownload(metric string) (float64, float64, error) {
+	up, down, found := strings.Cut(metric, "/")
+	if !found {
+		return math.NaN(), math.NaN(), fmt.Errorf("invalid upload/download value %q", metric)
+	}
+
+	u, err := ParseRate(up)
+	if err != nil {
+		return math.NaN(), math.NaN(), err
+	}
+	d, err := ParseRate(down)
+	if err != nil {
+		return math.NaN(), math.NaN(), err
+	}
+
+	return u, d, nil
+}
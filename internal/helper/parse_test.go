@@ -3,6 +3,7 @@ package helper
 import (
 	"math"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -105,6 +106,16 @@ func TestParseDuration(t *testing.T) {
 			4786440,
 			false,
 		},
+		{
+			"1m4s500ms",
+			64.5,
+			false,
+		},
+		{
+			"250ms",
+			0.25,
+			false,
+		},
 		{
 			"59",
 			0,
@@ -135,3 +146,201 @@ func TestParseDuration(t *testing.T) {
 		assert.Equal(t, testCase.output, f)
 	}
 }
+
+func TestParseMajorVersion(t *testing.T) {
+	var testCases = []struct {
+		input    string
+		output   int
+		hasError bool
+	}{
+		{"6.49.7 (long-term)", 6, false},
+		{"7.12.1 (stable)", 7, false},
+		{"7.1beta4", 7, false},
+		{"7", 7, false},
+		{"", 0, true},
+		{"v7.1", 0, true},
+	}
+
+	for _, testCase := range testCases {
+		v, err := ParseMajorVersion(testCase.input)
+
+		switch testCase.hasError {
+		case true:
+			assert.Error(t, err)
+		case false:
+			assert.NoError(t, err)
+		}
+
+		assert.Equal(t, testCase.output, v)
+	}
+}
+
+func TestSplitUploadDownload(t *testing.T) {
+	var testCases = []struct {
+		input    string
+		up       float64
+		down     float64
+		hasError bool
+	}{
+		{"1000/2000", 1000, 2000, false},
+		{"10M/512k", 10e6, 512e3, false},
+		{"0/0", 0, 0, false},
+		{"1G/", 0, 0, true},
+		{"1000", 0, 0, true},
+		{"x/1", 0, 0, true},
+	}
+
+	for _, testCase := range testCases {
+		up, down, err := SplitUploadDownload(testCase.input)
+
+		switch testCase.hasError {
+		case true:
+			assert.Error(t, err)
+			assert.True(t, math.IsNaN(up))
+			assert.True(t, math.IsNaN(down))
+		case false:
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.up, up)
+			assert.Equal(t, testCase.down, down)
+		}
+	}
+}
+
+func TestParseMinorVersion(t *testing.T) {
+	var testCases = []struct {
+		input    string
+		output   int
+		hasError bool
+	}{
+		{"6.49.7 (long-term)", 49, false},
+		{"7.13.2 (stable)", 13, false},
+		{"7.1beta4", 1, false},
+		{"7", 0, false},
+		{"7rc1", 0, false},
+		{"7.", 0, true},
+		{"", 0, true},
+	}
+
+	for _, testCase := range testCases {
+		v, err := ParseMinorVersion(testCase.input)
+
+		switch testCase.hasError {
+		case true:
+			assert.Error(t, err)
+		case false:
+			assert.NoError(t, err)
+		}
+
+		assert.Equal(t, testCase.output, v)
+	}
+}
+
+func TestParseWirelessRate(t *testing.T) {
+	var testCases = []struct {
+		input    string
+		output   float64
+		hasError bool
+	}{
+		{"144.4Mbps-20MHz/2S/SGI", 144.4e6, false},
+		{"6Mbps", 6e6, false},
+		{"866.7Mbps-80MHz/2S", 866.7e6, false},
+		{"1.2Gbps-160MHz/2S", 1.2e9, false},
+		{"", 0, true},
+		{"Mbps", 0, true},
+	}
+
+	for _, testCase := range testCases {
+		v, err := ParseWirelessRate(testCase.input)
+
+		switch testCase.hasError {
+		case true:
+			assert.Error(t, err)
+			assert.True(t, math.IsNaN(v))
+		case false:
+			assert.NoError(t, err)
+			assert.InDelta(t, testCase.output, v, 1e-6)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	var testCases = []struct {
+		input    string
+		output   time.Time
+		hasError bool
+	}{
+		{"jan/02/2024 13:04:05", time.Date(2024, 1, 2, 13, 4, 5, 0, time.UTC), false},
+		{"dec/31/2030 00:00:00", time.Date(2030, 12, 31, 0, 0, 0, 0, time.UTC), false},
+		{"2024-01-02 13:04:05", time.Date(2024, 1, 2, 13, 4, 5, 0, time.UTC), false},
+		{"2024-01-02", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), false},
+		{"", time.Time{}, true},
+		{"13:04:05", time.Time{}, true},
+	}
+
+	for _, testCase := range testCases {
+		v, err := ParseDate(testCase.input)
+
+		switch testCase.hasError {
+		case true:
+			assert.Error(t, err)
+		case false:
+			assert.NoError(t, err)
+		}
+
+		assert.Equal(t, testCase.output, v)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	var testCases = []struct {
+		input    string
+		output   float64
+		hasError bool
+	}{
+		{"2048KiB", 2048 * 1024, false},
+		{"4MiB", 4 * 1024 * 1024, false},
+		{"1GiB", 1024 * 1024 * 1024, false},
+		{"512", 512 * 1024, false},
+		{"KiB", 0, true},
+		{"", 0, true},
+	}
+
+	for _, testCase := range testCases {
+		v, err := ParseSize(testCase.input)
+
+		switch testCase.hasError {
+		case true:
+			assert.Error(t, err)
+			assert.True(t, math.IsNaN(v))
+		case false:
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.output, v)
+		}
+	}
+}
+
+func TestParseRTT(t *testing.T) {
+	var testCases = []struct {
+		input    string
+		output   float64
+		hasError bool
+	}{
+		{"12.34ms", 0.01234, false},
+		{"1ms234us", 0.001234, false},
+		{"1s", 1, false},
+		{"1d2h", 93600, false},
+		{"x", 0, true},
+	}
+
+	for _, testCase := range testCases {
+		v, err := ParseRTT(testCase.input)
+
+		switch testCase.hasError {
+		case true:
+			assert.Error(t, err)
+		case false:
+			assert.NoError(t, err)
+			assert.InDelta(t, testCase.output, v, 1e-9)
+		}
+	}
+}
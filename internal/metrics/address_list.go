@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"mikrotik-exporter/internal/collector"
+	"mikrotik-exporter/internal/config"
+	"mikrotik-exporter/internal/helper"
+)
+
+func init() {
+	Registry.Add("address_list", newAddressListCollector)
+}
+
+type addressListCollector struct {
+	lists       []string
+	ipv6Lists   []string
+	entriesDesc *prometheus.Desc
+}
+
+func newAddressListCollector() collector.Collector {
+	c := &addressListCollector{}
+	c.init()
+	return c
+}
+
+func (c *addressListCollector) init() {
+	labelNames := []string{"name", "address", "ip_version", "list", "dynamic"}
+	c.entriesDesc = helper.Description("address_list", "entries", "number of entries in the address list", labelNames)
+}
+
+func (c *addressListCollector) configure(cfg *config.Collectors) error {
+	// discovering the lists would transfer every entry and forget lists which became empty
+	if len(cfg.AddressList.Lists) == 0 && len(cfg.AddressList.IPv6Lists) == 0 {
+		return errors.New("no lists configured")
+	}
+
+	c.lists = cfg.AddressList.Lists
+	c.ipv6Lists = cfg.AddressList.IPv6Lists
+	return nil
+}
+
+func (c *addressListCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.entriesDesc
+}
+
+func (c *addressListCollector) Collect(ctx *collector.Context) error {
+	if err := c.collectTopic("ip", "4", c.lists, ctx); err != nil {
+		return err
+	}
+
+	return c.collectTopic("ipv6", "6", c.ipv6Lists, ctx)
+}
+
+func (c *addressListCollector) collectTopic(topic, ipVersion string, lists []string, ctx *collector.Context) error {
+	for _, list := range lists {
+		for _, dynamic := range []string{"true", "false"} {
+			if err := c.collectCount(topic, ipVersion, list, dynamic, ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *addressListCollector) collectCount(topic, ipVersion, list, dynamic string, ctx *collector.Context) error {
+	reply, err := ctx.Client.Run(fmt.Sprintf("/%s/firewall/address-list/print", topic),
+		fmt.Sprintf("?list=%s", list), fmt.Sprintf("?dynamic=%s", dynamic), "=count-only=")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"topic":  topic,
+			"list":   list,
+			"error":  err,
+		}).Error("error fetching address list counts")
+		return err
+	}
+	if reply.Done.Map["ret"] == "" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(reply.Done.Map["ret"], 64)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"topic":  topic,
+			"list":   list,
+			"error":  err,
+		}).Error("error parsing address list counts")
+		return err
+	}
+
+	ctx.Ch <- prometheus.MustNewConstMetric(c.entriesDesc, prometheus.GaugeValue, v, ctx.Device.Name, ctx.Device.Address, ipVersion, list, dynamic)
+	return nil
+}
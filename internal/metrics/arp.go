@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/internal/collector"
+	"mikrotik-exporter/internal/config"
+	"mikrotik-exporter/internal/helper"
+)
+
+func init() {
+	Registry.Add("arp", newARPCollector)
+}
+
+type arpCollector struct {
+	detailed    bool
+	entriesDesc *prometheus.Desc
+	entryDesc   *prometheus.Desc
+}
+
+func newARPCollector() collector.Collector {
+	c := &arpCollector{}
+	c.init()
+	return c
+}
+
+func (c *arpCollector) init() {
+	c.entriesDesc = helper.Description("arp", "entries", "number of ARP and IPv6 neighbor entries per interface and status",
+		[]string{"name", "address", "ip_version", "interface", "status"})
+	c.entryDesc = helper.Description("arp", "entry_info", "ARP or IPv6 neighbor entry",
+		[]string{"name", "address", "ip_version", "ip", "mac_address", "interface"})
+}
+
+func (c *arpCollector) configure(cfg *config.Collectors) error {
+	c.detailed = cfg.ARP.Detailed
+	return nil
+}
+
+func (c *arpCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.entriesDesc
+	ch <- c.entryDesc
+}
+
+func (c *arpCollector) Collect(ctx *collector.Context) error {
+	if err := c.collectTable("/ip/arp", "4", ctx); err != nil {
+		return err
+	}
+
+	return c.collectTable("/ipv6/neighbor", "6", ctx)
+}
+
+func (c *arpCollector) collectTable(menu, ipVersion string, ctx *collector.Context) error {
+	reply, err := ctx.Client.Run(menu+"/print", "=.proplist=address,mac-address,interface,status,dynamic,complete,invalid")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"menu":   menu,
+			"error":  err,
+		}).Error("error fetching arp entries")
+		return err
+	}
+
+	type entryKey struct{ iface, status string }
+
+	var keys []entryKey
+	counts := make(map[entryKey]float64)
+	for _, re := range reply.Re {
+		key := entryKey{re.Map["interface"], arpStatus(re)}
+		if _, exists := counts[key]; !exists {
+			keys = append(keys, key)
+		}
+		counts[key]++
+
+		if c.detailed {
+			ctx.Ch <- prometheus.MustNewConstMetric(c.entryDesc, prometheus.GaugeValue, 1, ctx.Device.Name, ctx.Device.Address,
+				ipVersion, re.Map["address"], re.Map["mac-address"], re.Map["interface"])
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].iface != keys[j].iface {
+			return keys[i].iface < keys[j].iface
+		}
+		return keys[i].status < keys[j].status
+	})
+
+	for _, key := range keys {
+		ctx.Ch <- prometheus.MustNewConstMetric(c.entriesDesc, prometheus.GaugeValue, counts[key], ctx.Device.Name, ctx.Device.Address,
+			ipVersion, key.iface, key.status)
+	}
+
+	return nil
+}
+
+// arpStatus returns the status of an entry, RouterOS 6 does not report it for ARP entries
+func arpStatus(re *proto.Sentence) string {
+	if status := re.Map["status"]; status != "" {
+		return status
+	}
+
+	switch {
+	case re.Map["invalid"] == "true":
+		return "failed"
+	case re.Map["complete"] == "false":
+		return "incomplete"
+	case re.Map["dynamic"] == "true":
+		return "reachable"
+	default:
+		return "permanent"
+	}
+}
@@ -76,6 +76,7 @@ func (c *bgpCollector) fetch(ctx *collector.Context) ([]*proto.Sentence, error)
 func (c *bgpCollector) collectForStat(re *proto.Sentence, ctx *collector.Context) {
 	asn := re.Map["remote-as"]
 	session := re.Map["name"]
+	ctx.ObserveState("bgp", session, re.Map["state"])
 
 	for _, p := range c.props[2:] {
 		c.collectMetricForProperty(p, session, asn, re, ctx)
@@ -0,0 +1,237 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/internal/collector"
+	"mikrotik-exporter/internal/config"
+	"mikrotik-exporter/internal/helper"
+)
+
+func init() {
+	Registry.Add("bgpv7", newBGPSessionCollector)
+}
+
+// bgpSessionCollector reads the RouterOS 7 BGP menus, bgpCollector covers the RouterOS 6 peer menu
+type bgpSessionCollector struct {
+	sessionProps           []string
+	connectionProps        []string
+	prefixesAccepted       bool
+	infoDesc               *prometheus.Desc
+	establishedDesc        *prometheus.Desc
+	uptimeDesc             *prometheus.Desc
+	holdTimeDesc           *prometheus.Desc
+	prefixesReceivedDesc   *prometheus.Desc
+	prefixesAdvertisedDesc *prometheus.Desc
+	prefixesAcceptedDesc   *prometheus.Desc
+	connectionDesc         *prometheus.Desc
+}
+
+func newBGPSessionCollector() collector.Collector {
+	c := &bgpSessionCollector{}
+	c.init()
+	return c
+}
+
+func (c *bgpSessionCollector) init() {
+	c.sessionProps = []string{"name", "established", "uptime", "hold-time", "prefix-count",
+		"local.role", "local.as", "local.address", "local.last-notification",
+		"remote.as", "remote.address", "remote.id", "remote.last-notification"}
+	c.connectionProps = []string{"name", "disabled", "as", "local.role", "remote.address", "remote.as"}
+
+	const prefix = "bgp_session"
+	labelNames := []string{"name", "address", "session"}
+	infoLabelNames := []string{"name", "address", "session", "local_role", "local_as", "local_address",
+		"remote_as", "remote_address", "remote_id", "last_notification_sent", "last_notification_received"}
+
+	c.infoDesc = helper.Description(prefix, "info", "BGP session details", infoLabelNames)
+	c.establishedDesc = helper.Description(prefix, "established", "BGP session is established (up = 1)", labelNames)
+	c.uptimeDesc = helper.Description(prefix, "uptime_seconds", "time since the BGP session was established in seconds", labelNames)
+	c.holdTimeDesc = helper.Description(prefix, "hold_time_seconds", "negotiated hold time in seconds", labelNames)
+	c.prefixesReceivedDesc = helper.Description(prefix, "prefixes_received", "number of prefixes received from the peer", labelNames)
+	c.prefixesAdvertisedDesc = helper.Description(prefix, "prefixes_advertised", "number of prefixes advertised to the peer", labelNames)
+	c.prefixesAcceptedDesc = helper.Description(prefix, "prefixes_accepted", "number of routes from the peer in the routing table", labelNames)
+	c.connectionDesc = helper.Description("bgp_connection", "info", "configured BGP connection (enabled = 1)",
+		[]string{"name", "address", "connection", "disabled", "as", "local_role", "remote_address", "remote_as"})
+}
+
+func (c *bgpSessionCollector) configure(cfg *config.Collectors) error {
+	c.prefixesAccepted = cfg.BGPv7.PrefixesAccepted
+	return nil
+}
+
+func (c *bgpSessionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.infoDesc
+	ch <- c.establishedDesc
+	ch <- c.uptimeDesc
+	ch <- c.holdTimeDesc
+	ch <- c.prefixesReceivedDesc
+	ch <- c.prefixesAdvertisedDesc
+	ch <- c.prefixesAcceptedDesc
+	ch <- c.connectionDesc
+}
+
+func (c *bgpSessionCollector) Collect(ctx *collector.Context) error {
+	if err := c.collectConnections(ctx); err != nil {
+		return err
+	}
+
+	reply, err := ctx.Client.Run("/routing/bgp/session/print", "=.proplist="+strings.Join(c.sessionProps, ","))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching bgp session metrics")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		if err := c.collectForSession(re, ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *bgpSessionCollector) collectConnections(ctx *collector.Context) error {
+	reply, err := ctx.Client.Run("/routing/bgp/connection/print", "=.proplist="+strings.Join(c.connectionProps, ","))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching bgp connections")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		v := 1.0
+		if strings.EqualFold(re.Map["disabled"], "true") {
+			v = 0.0
+		}
+
+		ctx.Ch <- prometheus.MustNewConstMetric(c.connectionDesc, prometheus.GaugeValue, v, ctx.Device.Name, ctx.Device.Address,
+			re.Map["name"], re.Map["disabled"], re.Map["as"], re.Map["local.role"], re.Map["remote.address"], re.Map["remote.as"])
+	}
+
+	return nil
+}
+
+func (c *bgpSessionCollector) collectForSession(re *proto.Sentence, ctx *collector.Context) error {
+	session := re.Map["name"]
+
+	ctx.Ch <- prometheus.MustNewConstMetric(c.infoDesc, prometheus.GaugeValue, 1, ctx.Device.Name, ctx.Device.Address, session,
+		re.Map["local.role"], re.Map["local.as"], re.Map["local.address"],
+		re.Map["remote.as"], re.Map["remote.address"], re.Map["remote.id"],
+		re.Map["local.last-notification"], re.Map["remote.last-notification"])
+
+	established := 0.0
+	if re.Map["established"] == "true" {
+		established = 1
+	}
+	ctx.Ch <- prometheus.MustNewConstMetric(c.establishedDesc, prometheus.GaugeValue, established, ctx.Device.Name, ctx.Device.Address, session)
+
+	c.collectDuration(c.uptimeDesc, "uptime", session, re, ctx)
+	c.collectDuration(c.holdTimeDesc, "hold-time", session, re, ctx)
+
+	if value := re.Map["prefix-count"]; value != "" {
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"device":   ctx.Device.Name,
+				"session":  session,
+				"property": "prefix-count",
+				"value":    value,
+				"error":    err,
+			}).Error("error parsing bgp session metric value")
+		} else {
+			ctx.Ch <- prometheus.MustNewConstMetric(c.prefixesReceivedDesc, prometheus.GaugeValue, v, ctx.Device.Name, ctx.Device.Address, session)
+		}
+	}
+
+	if established == 0 {
+		return nil
+	}
+
+	// advertisements are only listed if the connection keeps sent attributes
+	if err := c.collectCount(c.prefixesAdvertisedDesc, "/routing/bgp/advertisements/print", fmt.Sprintf("?peer=%s", session), session, ctx); err != nil {
+		return err
+	}
+
+	owner := bgpRouteOwner(re.Map["remote.address"])
+	if !c.prefixesAccepted || owner == "" {
+		return nil
+	}
+
+	// RouterOS 7 has no per session counter of accepted prefixes, the routing table is searched instead
+	return c.collectCount(c.prefixesAcceptedDesc, "/routing/route/print", fmt.Sprintf("?belongs-to=%s", owner), session, ctx)
+}
+
+// bgpRouteOwner returns the belongs-to value of routes learned from the peer, e.g. bgp-IP-192.0.2.1 or bgp-IP6-2001:db8::1
+func bgpRouteOwner(remoteAddress string) string {
+	ip := net.ParseIP(remoteAddress)
+	if ip == nil {
+		return ""
+	}
+
+	if ip.To4() != nil {
+		return "bgp-IP-" + ip.String()
+	}
+
+	return "bgp-IP6-" + ip.String()
+}
+
+func (c *bgpSessionCollector) collectDuration(desc *prometheus.Desc, property, session string, re *proto.Sentence, ctx *collector.Context) {
+	value := re.Map[property]
+	if value == "" {
+		return
+	}
+
+	v, err := helper.ParseDuration(value)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device":   ctx.Device.Name,
+			"session":  session,
+			"property": property,
+			"value":    value,
+			"error":    err,
+		}).Error("error parsing bgp session metric value")
+		return
+	}
+
+	ctx.Ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, ctx.Device.Name, ctx.Device.Address, session)
+}
+
+func (c *bgpSessionCollector) collectCount(desc *prometheus.Desc, cmd, query, session string, ctx *collector.Context) error {
+	reply, err := ctx.Client.Run(cmd, query, "=count-only=")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device":  ctx.Device.Name,
+			"session": session,
+			"error":   err,
+		}).Error("error fetching bgp session prefix counts")
+		return err
+	}
+	if reply.Done.Map["ret"] == "" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(reply.Done.Map["ret"], 64)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device":  ctx.Device.Name,
+			"session": session,
+			"error":   err,
+		}).Error("error parsing bgp session prefix counts")
+		return err
+	}
+
+	ctx.Ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, ctx.Device.Name, ctx.Device.Address, session)
+	return nil
+}
@@ -0,0 +1,25 @@
+package metrics
+
+import (
+	"testing"
+)
+
+func TestBGPRouteOwner(t *testing.T) {
+	owners := []struct {
+		address string
+		owner   string
+	}{
+		{"192.0.2.1", "bgp-IP-192.0.2.1"},
+		{"2001:db8::1", "bgp-IP6-2001:db8::1"},
+		{"2001:0db8:0000::0001", "bgp-IP6-2001:db8::1"},
+		{"", ""},
+		{"peer1", ""},
+	}
+
+	for _, o := range owners {
+		owner := bgpRouteOwner(o.address)
+		if owner != o.owner {
+			t.Errorf("owner : %q != %q for %q\n", owner, o.owner, o.address)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package metrics
+
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/internal/collector"
+	"mikrotik-exporter/internal/helper"
+)
+
+func init() {
+	Registry.Add("bridge", newBridgeCollector)
+}
+
+type bridgeCollector struct {
+	hostsDesc          *prometheus.Desc
+	portDesc           *prometheus.Desc
+	portForwardingDesc *prometheus.Desc
+	vlanDesc           *prometheus.Desc
+}
+
+func newBridgeCollector() collector.Collector {
+	c := &bridgeCollector{}
+	c.init()
+	return c
+}
+
+func (c *bridgeCollector) init() {
+	portLabelNames := []string{"name", "address", "bridge", "interface"}
+
+	c.hostsDesc = helper.Description("bridge", "hosts", "number of hosts in the bridge host table by type (local, learned or static)",
+		[]string{"name", "address", "bridge", "vlan", "type"})
+	c.portDesc = helper.Description("bridge_port", "stp_info", "STP role and state of the bridge port",
+		append(portLabelNames, "role", "state"))
+	c.portForwardingDesc = helper.Description("bridge_port", "forwarding", "bridge port is forwarding (forwarding = 1)", portLabelNames)
+	c.vlanDesc = helper.Description("bridge_vlan", "info", "VLAN membership of the bridge ports",
+		[]string{"name", "address", "bridge", "vlan_ids", "tagged", "untagged", "dynamic"})
+}
+
+func (c *bridgeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hostsDesc
+	ch <- c.portDesc
+	ch <- c.portForwardingDesc
+	ch <- c.vlanDesc
+}
+
+func (c *bridgeCollector) Collect(ctx *collector.Context) error {
+	if err := c.collectHosts(ctx); err != nil {
+		return err
+	}
+
+	if err := c.collectPorts(ctx); err != nil {
+		return err
+	}
+
+	return c.collectVLANs(ctx)
+}
+
+func (c *bridgeCollector) collectHosts(ctx *collector.Context) error {
+	reply, err := ctx.Client.Run("/interface/bridge/host/print", "=.proplist=bridge,vid,local,dynamic")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching bridge hosts")
+		return err
+	}
+
+	type hostKey struct{ bridge, vlan, hostType string }
+
+	var keys []hostKey
+	counts := make(map[hostKey]float64)
+	for _, re := range reply.Re {
+		key := hostKey{re.Map["bridge"], re.Map["vid"], bridgeHostType(re)}
+		if _, exists := counts[key]; !exists {
+			keys = append(keys, key)
+		}
+		counts[key]++
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].bridge != keys[j].bridge {
+			return keys[i].bridge < keys[j].bridge
+		}
+		if keys[i].vlan != keys[j].vlan {
+			return keys[i].vlan < keys[j].vlan
+		}
+		return keys[i].hostType < keys[j].hostType
+	})
+
+	for _, key := range keys {
+		ctx.Ch <- prometheus.MustNewConstMetric(c.hostsDesc, prometheus.GaugeValue, counts[key], ctx.Device.Name, ctx.Device.Address,
+			key.bridge, key.vlan, key.hostType)
+	}
+
+	return nil
+}
+
+func bridgeHostType(re *proto.Sentence) string {
+	switch {
+	case re.Map["local"] == "true":
+		return "local"
+	case re.Map["dynamic"] == "true":
+		return "learned"
+	default:
+		return "static"
+	}
+}
+
+func (c *bridgeCollector) collectPorts(ctx *collector.Context) error {
+	reply, err := ctx.Client.Run("/interface/bridge/port/print", "?disabled=false", "=.proplist=bridge,interface,role,inactive,learning,forwarding")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching bridge ports")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		state := "discarding"
+		switch {
+		case re.Map["inactive"] == "true":
+			state = "disabled"
+		case re.Map["forwarding"] == "true":
+			state = "forwarding"
+		case re.Map["learning"] == "true":
+			state = "learning"
+		}
+
+		ctx.Ch <- prometheus.MustNewConstMetric(c.portDesc, prometheus.GaugeValue, 1, ctx.Device.Name, ctx.Device.Address,
+			re.Map["bridge"], re.Map["interface"], re.Map["role"], state)
+
+		forwarding := 0.0
+		if state == "forwarding" {
+			forwarding = 1
+		}
+		ctx.Ch <- prometheus.MustNewConstMetric(c.portForwardingDesc, prometheus.GaugeValue, forwarding, ctx.Device.Name, ctx.Device.Address,
+			re.Map["bridge"], re.Map["interface"])
+	}
+
+	return nil
+}
+
+func (c *bridgeCollector) collectVLANs(ctx *collector.Context) error {
+	reply, err := ctx.Client.Run("/interface/bridge/vlan/print", "?disabled=false", "=.proplist=bridge,vlan-ids,current-tagged,current-untagged,dynamic")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching bridge vlans")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		ctx.Ch <- prometheus.MustNewConstMetric(c.vlanDesc, prometheus.GaugeValue, 1, ctx.Device.Name, ctx.Device.Address,
+			re.Map["bridge"], re.Map["vlan-ids"], re.Map["current-tagged"], re.Map["current-untagged"], re.Map["dynamic"])
+	}
+
+	return nil
+}
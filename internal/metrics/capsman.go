@@ -0,0 +1,451 @@
+package metrics
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/internal/collector"
+	"mikrotik-exporter/internal/helper"
+)
+
+func init() {
+	Registry.Add("capsman", newCAPsMANCollector)
+}
+
+// capsmanMenu describes where a wireless package keeps its CAPsMAN state
+type capsmanMenu struct {
+	registrationTable string
+	remoteCAP         string
+	iface             string
+	monitor           string
+	signalProperty    string
+	boardProperty     string
+	legacy            bool
+}
+
+var (
+	capsmanLegacyMenu = capsmanMenu{
+		registrationTable: "/caps-man/registration-table",
+		remoteCAP:         "/caps-man/remote-cap",
+		iface:             "/caps-man/interface",
+		signalProperty:    "rx-signal",
+		boardProperty:     "board",
+		legacy:            true,
+	}
+	// RouterOS 7.13 renamed the wifiwave2 menus to wifi
+	capsmanWifiMenu = capsmanMenu{
+		registrationTable: "/interface/wifi/registration-table",
+		remoteCAP:         "/interface/wifi/capsman/remote-cap",
+		iface:             "/interface/wifi",
+		monitor:           "/interface/wifi/monitor",
+		signalProperty:    "signal",
+		boardProperty:     "board-name",
+	}
+	capsmanWifiwave2Menu = capsmanMenu{
+		registrationTable: "/interface/wifiwave2/registration-table",
+		remoteCAP:         "/interface/wifiwave2/capsman/remote-cap",
+		iface:             "/interface/wifiwave2",
+		monitor:           "/interface/wifiwave2/monitor",
+		signalProperty:    "signal",
+		boardProperty:     "board-name",
+	}
+)
+
+// capsmanClients are the clients of an interface in the registration table
+type capsmanClients struct {
+	registered float64
+	authorized float64
+}
+
+type capsmanCollector struct {
+	signalDesc            *prometheus.Desc
+	txRateDesc            *prometheus.Desc
+	rxRateDesc            *prometheus.Desc
+	txBytesDesc           *prometheus.Desc
+	rxBytesDesc           *prometheus.Desc
+	txPacketsDesc         *prometheus.Desc
+	rxPacketsDesc         *prometheus.Desc
+	uptimeDesc            *prometheus.Desc
+	remoteCAPDesc         *prometheus.Desc
+	remoteCAPRunningDesc  *prometheus.Desc
+	registeredClientsDesc *prometheus.Desc
+	authorizedClientsDesc *prometheus.Desc
+	frequencyDesc         *prometheus.Desc
+}
+
+func newCAPsMANCollector() collector.Collector {
+	c := &capsmanCollector{}
+	c.init()
+	return c
+}
+
+func (c *capsmanCollector) init() {
+	const prefix = "capsman_station"
+
+	labelNames := []string{"name", "address", "interface", "mac_address", "ssid"}
+	c.signalDesc = helper.Description(prefix, "signal_strength", "signal strength of the station in dBm", labelNames)
+	c.txRateDesc = helper.Description(prefix, "tx_rate", "rate to the station in bits per second", labelNames)
+	c.rxRateDesc = helper.Description(prefix, "rx_rate", "rate from the station in bits per second", labelNames)
+	c.txBytesDesc = helper.Description(prefix, "tx_bytes", "number of bytes sent to the station", labelNames)
+	c.rxBytesDesc = helper.Description(prefix, "rx_bytes", "number of bytes received from the station", labelNames)
+	c.txPacketsDesc = helper.Description(prefix, "tx_packets", "number of packets sent to the station", labelNames)
+	c.rxPacketsDesc = helper.Description(prefix, "rx_packets", "number of packets received from the station", labelNames)
+	c.uptimeDesc = helper.Description(prefix, "uptime_seconds", "time since the station associated in seconds", labelNames)
+
+	c.remoteCAPDesc = helper.Description("capsman_remote_cap", "info", "CAP managed by this CAPsMAN", []string{"name", "address", "identity", "cap_address", "board", "version", "state"})
+	c.remoteCAPRunningDesc = helper.Description("capsman_remote_cap", "running", "CAP is provisioned and running (running = 1)", []string{"name", "address", "identity", "cap_address"})
+
+	interfaceLabelNames := []string{"name", "address", "interface", "master_interface", "radio_mac", "channel"}
+	c.registeredClientsDesc = helper.Description("capsman_interface", "registered_clients", "number of clients registered on the CAP interface", interfaceLabelNames)
+	c.authorizedClientsDesc = helper.Description("capsman_interface", "authorized_clients", "number of clients authorized on the CAP interface", interfaceLabelNames)
+	c.frequencyDesc = helper.Description("capsman_interface", "frequency_mhz", "current frequency of the CAP interface in MHz", interfaceLabelNames)
+}
+
+func (c *capsmanCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.signalDesc
+	ch <- c.txRateDesc
+	ch <- c.rxRateDesc
+	ch <- c.txBytesDesc
+	ch <- c.rxBytesDesc
+	ch <- c.txPacketsDesc
+	ch <- c.rxPacketsDesc
+	ch <- c.uptimeDesc
+	ch <- c.remoteCAPDesc
+	ch <- c.remoteCAPRunningDesc
+	ch <- c.registeredClientsDesc
+	ch <- c.authorizedClientsDesc
+	ch <- c.frequencyDesc
+}
+
+func (c *capsmanCollector) Collect(ctx *collector.Context) error {
+	menus, err := c.menus(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, menu := range menus {
+		if err := c.collectMenu(menu, ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// menus returns the CAPsMAN menus available on the device
+func (c *capsmanCollector) menus(ctx *collector.Context) ([]capsmanMenu, error) {
+	major, err := ctx.MajorVersion()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching RouterOS version")
+		return nil, err
+	}
+
+	if major > 7 {
+		return c.menusForPackages(ctx, capsmanWifiMenu)
+	}
+
+	if major == 7 {
+		minor, err := ctx.MinorVersion()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"device": ctx.Device.Name,
+				"error":  err,
+			}).Error("error fetching RouterOS version")
+			return nil, err
+		}
+
+		// CAPsMAN for wifi is part of the base package since 7.13
+		if minor >= 13 {
+			return c.menusForPackages(ctx, capsmanWifiMenu)
+		}
+	}
+
+	return c.menusForPackages(ctx)
+}
+
+// menusForPackages adds the menus of the installed wireless packages, the legacy wireless
+// package and the RouterOS 7 wifi packages can be installed side by side
+func (c *capsmanCollector) menusForPackages(ctx *collector.Context, menus ...capsmanMenu) ([]capsmanMenu, error) {
+	reply, err := ctx.Client.Run("/system/package/print", "?disabled=false", "=.proplist=name")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching packages")
+		return nil, err
+	}
+
+	for _, re := range reply.Re {
+		switch name := re.Map["name"]; {
+		case strings.HasPrefix(name, "wireless"):
+			menus = append([]capsmanMenu{capsmanLegacyMenu}, menus...)
+		case name == "wifiwave2":
+			menus = append(menus, capsmanWifiwave2Menu)
+		}
+	}
+
+	return menus, nil
+}
+
+func (c *capsmanCollector) collectMenu(menu capsmanMenu, ctx *collector.Context) error {
+	clients, err := c.collectRegistrationTable(menu, ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.collectRemoteCAPs(menu, ctx); err != nil {
+		return err
+	}
+
+	if menu.legacy {
+		return c.collectLegacyInterfaces(menu, ctx)
+	}
+
+	return c.collectInterfaces(menu, clients, ctx)
+}
+
+// collectRegistrationTable returns the stations per interface
+func (c *capsmanCollector) collectRegistrationTable(menu capsmanMenu, ctx *collector.Context) (map[string]*capsmanClients, error) {
+	props := []string{"interface", "mac-address", "ssid", menu.signalProperty, "tx-rate", "rx-rate", "uptime", "bytes", "packets", "authorized"}
+
+	reply, err := ctx.Client.Run(menu.registrationTable+"/print", "=.proplist="+strings.Join(props, ","))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"menu":   menu.registrationTable,
+			"error":  err,
+		}).Error("error fetching capsman registration table")
+		return nil, err
+	}
+
+	clients := make(map[string]*capsmanClients)
+	for _, re := range reply.Re {
+		iface := re.Map["interface"]
+		if clients[iface] == nil {
+			clients[iface] = &capsmanClients{}
+		}
+		clients[iface].registered++
+		if re.Map["authorized"] == "true" {
+			clients[iface].authorized++
+		}
+
+		c.collectForStation(menu, re, ctx)
+	}
+
+	return clients, nil
+}
+
+func (c *capsmanCollector) collectForStation(menu capsmanMenu, re *proto.Sentence, ctx *collector.Context) {
+	labelValues := []string{ctx.Device.Name, ctx.Device.Address, re.Map["interface"], re.Map["mac-address"], re.Map["ssid"]}
+
+	c.collectStationValue(c.signalDesc, menu.signalProperty, prometheus.GaugeValue, nil, labelValues, re, ctx)
+	c.collectStationValue(c.txRateDesc, "tx-rate", prometheus.GaugeValue, helper.ParseWirelessRate, labelValues, re, ctx)
+	c.collectStationValue(c.rxRateDesc, "rx-rate", prometheus.GaugeValue, helper.ParseWirelessRate, labelValues, re, ctx)
+	c.collectStationValue(c.uptimeDesc, "uptime", prometheus.GaugeValue, helper.ParseDuration, labelValues, re, ctx)
+	c.collectStationCounters(c.txBytesDesc, c.rxBytesDesc, "bytes", labelValues, re, ctx)
+	c.collectStationCounters(c.txPacketsDesc, c.rxPacketsDesc, "packets", labelValues, re, ctx)
+}
+
+// collectStationValue parses the value with parse, or as plain number if parse is nil
+func (c *capsmanCollector) collectStationValue(desc *prometheus.Desc, property string, valueType prometheus.ValueType,
+	parse func(string) (float64, error), labelValues []string, re *proto.Sentence, ctx *collector.Context) {
+	value := re.Map[property]
+	if value == "" {
+		return
+	}
+
+	var v float64
+	var err error
+	if parse == nil {
+		v, err = strconv.ParseFloat(value, 64)
+	} else {
+		v, err = parse(value)
+	}
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device":   ctx.Device.Name,
+			"station":  re.Map["mac-address"],
+			"property": property,
+			"value":    value,
+			"error":    err,
+		}).Error("error parsing capsman station metric value")
+		return
+	}
+
+	ctx.Ch <- prometheus.MustNewConstMetric(desc, valueType, v, labelValues...)
+}
+
+func (c *capsmanCollector) collectStationCounters(txDesc, rxDesc *prometheus.Desc, property string, labelValues []string, re *proto.Sentence, ctx *collector.Context) {
+	value := re.Map[property]
+	if value == "" {
+		return
+	}
+
+	tx, rx, err := helper.SplitStringToFloats(value)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device":   ctx.Device.Name,
+			"station":  re.Map["mac-address"],
+			"property": property,
+			"value":    value,
+			"error":    err,
+		}).Error("error parsing capsman station metric value")
+		return
+	}
+
+	ctx.Ch <- prometheus.MustNewConstMetric(txDesc, prometheus.CounterValue, tx, labelValues...)
+	ctx.Ch <- prometheus.MustNewConstMetric(rxDesc, prometheus.CounterValue, rx, labelValues...)
+}
+
+func (c *capsmanCollector) collectRemoteCAPs(menu capsmanMenu, ctx *collector.Context) error {
+	reply, err := ctx.Client.Run(menu.remoteCAP+"/print", "=.proplist=identity,address,"+menu.boardProperty+",version,state")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"menu":   menu.remoteCAP,
+			"error":  err,
+		}).Error("error fetching capsman remote caps")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		ctx.Ch <- prometheus.MustNewConstMetric(c.remoteCAPDesc, prometheus.GaugeValue, 1, ctx.Device.Name, ctx.Device.Address,
+			re.Map["identity"], re.Map["address"], re.Map[menu.boardProperty], re.Map["version"], re.Map["state"])
+
+		running := 0.0
+		if strings.EqualFold(re.Map["state"], "run") || strings.EqualFold(re.Map["state"], "ok") {
+			running = 1
+		}
+		ctx.Ch <- prometheus.MustNewConstMetric(c.remoteCAPRunningDesc, prometheus.GaugeValue, running, ctx.Device.Name, ctx.Device.Address,
+			re.Map["identity"], re.Map["address"])
+	}
+
+	return nil
+}
+
+// collectLegacyInterfaces reads channel and client counts the legacy CAPsMAN reports per CAP interface
+func (c *capsmanCollector) collectLegacyInterfaces(menu capsmanMenu, ctx *collector.Context) error {
+	reply, err := ctx.Client.Run(menu.iface+"/print", "=.proplist=name,master-interface,radio-mac,current-channel,current-registered-clients,current-authorized-clients")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"menu":   menu.iface,
+			"error":  err,
+		}).Error("error fetching capsman interfaces")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		labelValues := []string{ctx.Device.Name, ctx.Device.Address, re.Map["name"], re.Map["master-interface"], re.Map["radio-mac"], re.Map["current-channel"]}
+
+		c.collectInterfaceValue(c.registeredClientsDesc, "current-registered-clients", labelValues, re, ctx)
+		c.collectInterfaceValue(c.authorizedClientsDesc, "current-authorized-clients", labelValues, re, ctx)
+
+		// current-channel looks like 5180/20-Ceee/ac/P(17dBm)
+		if v, ok := channelFrequency(re.Map["current-channel"]); ok {
+			ctx.Ch <- prometheus.MustNewConstMetric(c.frequencyDesc, prometheus.GaugeValue, v, labelValues...)
+		}
+	}
+
+	return nil
+}
+
+// collectInterfaces reports the client counts of the wifi interfaces based on the registration table
+// and the current channel reported by the interface monitor
+func (c *capsmanCollector) collectInterfaces(menu capsmanMenu, clients map[string]*capsmanClients, ctx *collector.Context) error {
+	reply, err := ctx.Client.Run(menu.iface+"/print", "=.proplist=name,master-interface,mac-address")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"menu":   menu.iface,
+			"error":  err,
+		}).Error("error fetching capsman interfaces")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		name := re.Map["name"]
+		channel := c.fetchChannel(menu, name, ctx)
+		labelValues := []string{ctx.Device.Name, ctx.Device.Address, name, re.Map["master-interface"], re.Map["mac-address"], channel}
+
+		ifaceClients := clients[name]
+		if ifaceClients == nil {
+			ifaceClients = &capsmanClients{}
+		}
+		ctx.Ch <- prometheus.MustNewConstMetric(c.registeredClientsDesc, prometheus.GaugeValue, ifaceClients.registered, labelValues...)
+		ctx.Ch <- prometheus.MustNewConstMetric(c.authorizedClientsDesc, prometheus.GaugeValue, ifaceClients.authorized, labelValues...)
+
+		// channel looks like 5180/ax/Ceee
+		if v, ok := channelFrequency(channel); ok {
+			ctx.Ch <- prometheus.MustNewConstMetric(c.frequencyDesc, prometheus.GaugeValue, v, labelValues...)
+		}
+	}
+
+	return nil
+}
+
+// fetchChannel returns the current channel of a wifi interface, empty if the interface is not running
+func (c *capsmanCollector) fetchChannel(menu capsmanMenu, iface string, ctx *collector.Context) string {
+	reply, err := ctx.Client.Run(menu.monitor, "=numbers="+iface, "=once=")
+	if err != nil {
+		// disabled and unprovisioned interfaces can not be monitored
+		log.WithFields(log.Fields{
+			"device":    ctx.Device.Name,
+			"interface": iface,
+			"error":     err,
+		}).Debug("error monitoring capsman interface")
+		return ""
+	}
+
+	if len(reply.Re) == 0 {
+		return ""
+	}
+
+	return reply.Re[0].Map["channel"]
+}
+
+func (c *capsmanCollector) collectInterfaceValue(desc *prometheus.Desc, property string, labelValues []string, re *proto.Sentence, ctx *collector.Context) {
+	value := re.Map[property]
+	if value == "" {
+		return
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device":    ctx.Device.Name,
+			"interface": re.Map["name"],
+			"property":  property,
+			"value":     value,
+			"error":     err,
+		}).Error("error parsing capsman interface metric value")
+		return
+	}
+
+	ctx.Ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, labelValues...)
+}
+
+// channelFrequency returns the frequency in MHz at the start of a channel description
+func channelFrequency(channel string) (float64, bool) {
+	i := strings.IndexFunc(channel, func(r rune) bool { return r < '0' || r > '9' })
+	if i == 0 || channel == "" {
+		return 0, false
+	}
+	if i < 0 {
+		i = len(channel)
+	}
+
+	v, err := strconv.ParseFloat(channel[:i], 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return v, true
+}
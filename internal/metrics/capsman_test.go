@@ -0,0 +1,26 @@
+package metrics
+
+import (
+	"testing"
+)
+
+func TestChannelFrequency(t *testing.T) {
+	channels := []struct {
+		channel   string
+		frequency float64
+		ok        bool
+	}{
+		{"5180/20-Ceee/ac/P(17dBm)", 5180, true},
+		{"5180/ax/Ceee", 5180, true},
+		{"2412", 2412, true},
+		{"", 0, false},
+		{"auto", 0, false},
+	}
+
+	for _, ch := range channels {
+		frequency, ok := channelFrequency(ch.channel)
+		if ok != ch.ok || frequency != ch.frequency {
+			t.Errorf("frequency : %f, %t != %f, %t for %q\n", frequency, ok, ch.frequency, ch.ok, ch.channel)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/internal/collector"
+	"mikrotik-exporter/internal/helper"
+)
+
+func init() {
+	Registry.Add("certificate", newCertificateCollector)
+}
+
+type certificateCollector struct {
+	notBeforeDesc *prometheus.Desc
+	notAfterDesc  *prometheus.Desc
+	expiryDesc    *prometheus.Desc
+	trustedDesc   *prometheus.Desc
+	revokedDesc   *prometheus.Desc
+	caDesc        *prometheus.Desc
+}
+
+func newCertificateCollector() collector.Collector {
+	c := &certificateCollector{}
+	c.init()
+	return c
+}
+
+func (c *certificateCollector) init() {
+	const prefix = "certificate"
+
+	labelNames := []string{"name", "address", "certificate", "common_name", "fingerprint"}
+	c.notBeforeDesc = helper.Description(prefix, "not_before_timestamp_seconds", "start of the validity of the certificate as unix timestamp", labelNames)
+	c.notAfterDesc = helper.Description(prefix, "not_after_timestamp_seconds", "end of the validity of the certificate as unix timestamp", labelNames)
+	c.expiryDesc = helper.Description(prefix, "expiry_days", "days until the certificate expires, negative if expired", labelNames)
+	c.trustedDesc = helper.Description(prefix, "trusted", "certificate is trusted (trusted = 1)", labelNames)
+	c.revokedDesc = helper.Description(prefix, "revoked", "certificate is revoked (revoked = 1)", labelNames)
+	c.caDesc = helper.Description(prefix, "ca", "certificate is a certificate authority (ca = 1)", labelNames)
+}
+
+func (c *certificateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.notBeforeDesc
+	ch <- c.notAfterDesc
+	ch <- c.expiryDesc
+	ch <- c.trustedDesc
+	ch <- c.revokedDesc
+	ch <- c.caDesc
+}
+
+func (c *certificateCollector) Collect(ctx *collector.Context) error {
+	reply, err := ctx.Client.Run("/certificate/print",
+		"=.proplist=name,common-name,fingerprint,invalid-before,invalid-after,trusted,revoked,authority")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching certificates")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		c.collectForCertificate(re, ctx)
+	}
+
+	return nil
+}
+
+func (c *certificateCollector) collectForCertificate(re *proto.Sentence, ctx *collector.Context) {
+	labelValues := []string{ctx.Device.Name, ctx.Device.Address, re.Map["name"], re.Map["common-name"], re.Map["fingerprint"]}
+
+	if notBefore, ok := c.parseDate("invalid-before", re, ctx); ok {
+		ctx.Ch <- prometheus.MustNewConstMetric(c.notBeforeDesc, prometheus.GaugeValue, float64(notBefore.Unix()), labelValues...)
+	}
+
+	if notAfter, ok := c.parseDate("invalid-after", re, ctx); ok {
+		ctx.Ch <- prometheus.MustNewConstMetric(c.notAfterDesc, prometheus.GaugeValue, float64(notAfter.Unix()), labelValues...)
+		ctx.Ch <- prometheus.MustNewConstMetric(c.expiryDesc, prometheus.GaugeValue, time.Until(notAfter).Hours()/24, labelValues...)
+	}
+
+	ctx.Ch <- prometheus.MustNewConstMetric(c.trustedDesc, prometheus.GaugeValue, boolToFloat(re.Map["trusted"] == "true"), labelValues...)
+	ctx.Ch <- prometheus.MustNewConstMetric(c.revokedDesc, prometheus.GaugeValue, boolToFloat(re.Map["revoked"] == "true"), labelValues...)
+	// ca is the name of the signing CA, certificate authorities themselves have the authority flag
+	ctx.Ch <- prometheus.MustNewConstMetric(c.caDesc, prometheus.GaugeValue, boolToFloat(re.Map["authority"] == "true"), labelValues...)
+}
+
+func (c *certificateCollector) parseDate(property string, re *proto.Sentence, ctx *collector.Context) (time.Time, bool) {
+	value := re.Map[property]
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	t, err := helper.ParseDate(value)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device":      ctx.Device.Name,
+			"certificate": re.Map["name"],
+			"property":    property,
+			"value":       value,
+			"error":       err,
+		}).Error("error parsing certificate metric value")
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,161 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/internal/collector"
+	"mikrotik-exporter/internal/config"
+	"mikrotik-exporter/internal/helper"
+)
+
+func init() {
+	Registry.Add("cpu", newCPUCollector)
+}
+
+// cpuDefaultProfileDuration keeps the profile sample well below the collection timeout
+const cpuDefaultProfileDuration = time.Second
+
+type cpuCollector struct {
+	props           []string
+	descriptions    map[string]*prometheus.Desc
+	profile         bool
+	profileDuration time.Duration
+	profileDesc     *prometheus.Desc
+}
+
+func newCPUCollector() collector.Collector {
+	c := &cpuCollector{}
+	c.init()
+	return c
+}
+
+func (c *cpuCollector) init() {
+	c.props = []string{"cpu", "load", "irq", "disk"}
+
+	labelNames := []string{"name", "address", "cpu"}
+	c.descriptions = map[string]*prometheus.Desc{
+		"load": helper.Description("cpu", "load", "load of the core in percent", labelNames),
+		"irq":  helper.Description("cpu", "irq", "share of the core spent on interrupts in percent", labelNames),
+		"disk": helper.Description("cpu", "disk", "share of the core spent on disk access in percent", labelNames),
+	}
+	c.profileDesc = helper.Description("cpu_profile", "usage", "CPU usage of a subsystem in percent from /tool/profile",
+		[]string{"name", "address", "classifier"})
+	c.profileDuration = cpuDefaultProfileDuration
+}
+
+func (c *cpuCollector) configure(cfg *config.Collectors) error {
+	c.profile = cfg.CPU.Profile
+	if cfg.CPU.ProfileDuration > 0 {
+		c.profileDuration = cfg.CPU.ProfileDuration
+	}
+
+	return nil
+}
+
+func (c *cpuCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range c.descriptions {
+		ch <- d
+	}
+	ch <- c.profileDesc
+}
+
+func (c *cpuCollector) Collect(ctx *collector.Context) error {
+	reply, err := ctx.Client.Run("/system/resource/cpu/print", "=.proplist="+strings.Join(c.props, ","))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching cpu metrics")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		for _, p := range c.props[1:] {
+			c.collectMetricForProperty(p, re, ctx)
+		}
+	}
+
+	if !c.profile {
+		return nil
+	}
+
+	return c.collectProfile(ctx)
+}
+
+func (c *cpuCollector) collectMetricForProperty(property string, re *proto.Sentence, ctx *collector.Context) {
+	value := re.Map[property]
+	if value == "" {
+		return
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device":   ctx.Device.Name,
+			"cpu":      re.Map["cpu"],
+			"property": property,
+			"value":    value,
+			"error":    err,
+		}).Error("error parsing cpu metric value")
+		return
+	}
+
+	ctx.Ch <- prometheus.MustNewConstMetric(c.descriptions[property], prometheus.GaugeValue, v, ctx.Device.Name, ctx.Device.Address, re.Map["cpu"])
+}
+
+// collectProfile samples /tool/profile for the configured duration, the profiler reports
+// every second so the last reported usage of a classifier is exported
+func (c *cpuCollector) collectProfile(ctx *collector.Context) error {
+	seconds := int(c.profileDuration.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	duration := fmt.Sprintf("=duration=%ds", seconds)
+
+	reply, err := ctx.Client.Run("/tool/profile", "=cpu=total", duration)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching cpu profile")
+		return err
+	}
+
+	usage := make(map[string]string)
+	for _, re := range reply.Re {
+		if name := re.Map["name"]; name != "" {
+			usage[name] = re.Map["usage"]
+		}
+	}
+
+	classifiers := make([]string, 0, len(usage))
+	for classifier := range usage {
+		classifiers = append(classifiers, classifier)
+	}
+	sort.Strings(classifiers)
+
+	for _, classifier := range classifiers {
+		v, err := strconv.ParseFloat(usage[classifier], 64)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"device":     ctx.Device.Name,
+				"classifier": classifier,
+				"value":      usage[classifier],
+				"error":      err,
+			}).Error("error parsing cpu profile metric value")
+			continue
+		}
+
+		ctx.Ch <- prometheus.MustNewConstMetric(c.profileDesc, prometheus.GaugeValue, v, ctx.Device.Name, ctx.Device.Address, classifier)
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/internal/collector"
+	"mikrotik-exporter/internal/helper"
+)
+
+func init() {
+	Registry.Add("dns", newDNSCollector)
+}
+
+type dnsCollector struct {
+	cacheSizeDesc     *prometheus.Desc
+	cacheUsedDesc     *prometheus.Desc
+	remoteDesc        *prometheus.Desc
+	serverDesc        *prometheus.Desc
+	cacheEntriesDesc  *prometheus.Desc
+	staticEntriesDesc *prometheus.Desc
+}
+
+func newDNSCollector() collector.Collector {
+	c := &dnsCollector{}
+	c.init()
+	return c
+}
+
+func (c *dnsCollector) init() {
+	const prefix = "dns"
+
+	labelNames := []string{"name", "address"}
+	c.cacheSizeDesc = helper.Description(prefix, "cache_size_bytes", "configured size of the DNS cache in bytes", labelNames)
+	c.cacheUsedDesc = helper.Description(prefix, "cache_used_bytes", "used size of the DNS cache in bytes", labelNames)
+	c.remoteDesc = helper.Description(prefix, "allow_remote_requests", "DNS requests from other hosts are allowed (allowed = 1)", labelNames)
+	c.serverDesc = helper.Description(prefix, "server_info", "upstream DNS server", []string{"name", "address", "server", "dynamic"})
+	c.cacheEntriesDesc = helper.Description(prefix, "cache_entries", "number of cached entries by record type", []string{"name", "address", "type"})
+	c.staticEntriesDesc = helper.Description(prefix, "static_entries", "number of enabled static entries", labelNames)
+}
+
+func (c *dnsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.cacheSizeDesc
+	ch <- c.cacheUsedDesc
+	ch <- c.remoteDesc
+	ch <- c.serverDesc
+	ch <- c.cacheEntriesDesc
+	ch <- c.staticEntriesDesc
+}
+
+func (c *dnsCollector) Collect(ctx *collector.Context) error {
+	reply, err := ctx.Client.Run("/ip/dns/print", "=.proplist=servers,dynamic-servers,allow-remote-requests,cache-size,cache-used")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching dns settings")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		c.collectSettings(re, ctx)
+	}
+
+	if err := c.collectCacheEntries(ctx); err != nil {
+		return err
+	}
+
+	return c.collectCount(c.staticEntriesDesc, "/ip/dns/static/print", "?disabled=false", ctx)
+}
+
+// collectCacheEntries counts the cached records per type, /ip/dns/cache does not list
+// every record type so the records are read from /ip/dns/cache/all
+func (c *dnsCollector) collectCacheEntries(ctx *collector.Context) error {
+	reply, err := ctx.Client.Run("/ip/dns/cache/all/print", "=.proplist=type")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching dns cache entries")
+		return err
+	}
+
+	counts := make(map[string]float64)
+	for _, re := range reply.Re {
+		counts[re.Map["type"]]++
+	}
+
+	types := make([]string, 0, len(counts))
+	for t := range counts {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	for _, t := range types {
+		ctx.Ch <- prometheus.MustNewConstMetric(c.cacheEntriesDesc, prometheus.GaugeValue, counts[t], ctx.Device.Name, ctx.Device.Address, t)
+	}
+
+	return nil
+}
+
+func (c *dnsCollector) collectSettings(re *proto.Sentence, ctx *collector.Context) {
+	for desc, property := range map[*prometheus.Desc]string{c.cacheSizeDesc: "cache-size", c.cacheUsedDesc: "cache-used"} {
+		value := re.Map[property]
+		if value == "" {
+			continue
+		}
+
+		v, err := helper.ParseSize(value)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"device":   ctx.Device.Name,
+				"property": property,
+				"value":    value,
+				"error":    err,
+			}).Error("error parsing dns metric value")
+			continue
+		}
+
+		ctx.Ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, ctx.Device.Name, ctx.Device.Address)
+	}
+
+	remote := 0.0
+	if re.Map["allow-remote-requests"] == "true" {
+		remote = 1
+	}
+	ctx.Ch <- prometheus.MustNewConstMetric(c.remoteDesc, prometheus.GaugeValue, remote, ctx.Device.Name, ctx.Device.Address)
+
+	for property, dynamic := range map[string]string{"servers": "false", "dynamic-servers": "true"} {
+		for _, server := range strings.Split(re.Map[property], ",") {
+			if server == "" {
+				continue
+			}
+
+			ctx.Ch <- prometheus.MustNewConstMetric(c.serverDesc, prometheus.GaugeValue, 1, ctx.Device.Name, ctx.Device.Address, server, dynamic)
+		}
+	}
+}
+
+func (c *dnsCollector) collectCount(desc *prometheus.Desc, cmd, query string, ctx *collector.Context, labelValues ...string) error {
+	reply, err := ctx.Client.Run(cmd, query, "=count-only=")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching dns entry counts")
+		return err
+	}
+	if reply.Done.Map["ret"] == "" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(reply.Done.Map["ret"], 64)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error parsing dns entry counts")
+		return err
+	}
+
+	ctx.Ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, append([]string{ctx.Device.Name, ctx.Device.Address}, labelValues...)...)
+	return nil
+}
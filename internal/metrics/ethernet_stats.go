@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/internal/collector"
+	"mikrotik-exporter/internal/helper"
+)
+
+func init() {
+	Registry.Add("ethernet_stats", newEthernetStatsCollector)
+}
+
+// ethernetFrameSizes are the frame size buckets of the per-size counters, e.g. rx-65-127
+var ethernetFrameSizes = []string{"64", "65-127", "128-255", "256-511", "512-1023", "1024-1518", "1519-max"}
+
+type ethernetStatsCollector struct {
+	props        []string
+	sizeProps    []string
+	descriptions map[string]*prometheus.Desc
+	rxFramesDesc *prometheus.Desc
+	txFramesDesc *prometheus.Desc
+}
+
+func newEthernetStatsCollector() collector.Collector {
+	c := &ethernetStatsCollector{}
+	c.init()
+	return c
+}
+
+func (c *ethernetStatsCollector) init() {
+	// not every switch chip reports every counter, missing counters are skipped
+	c.props = []string{"rx-fcs-error", "rx-align-error", "rx-fragment", "rx-too-short", "rx-too-long", "rx-jabber",
+		"rx-overflow", "rx-length-error", "rx-code-error", "rx-carrier-error", "rx-unknown-op", "rx-drop", "rx-pause",
+		"rx-broadcast", "rx-multicast", "tx-pause", "tx-collision", "tx-single-collision", "tx-multiple-collision",
+		"tx-excessive-collision", "tx-late-collision", "tx-deferred", "tx-excessive-deferred", "tx-underrun", "tx-drop",
+		"tx-fcs-error", "tx-broadcast", "tx-multicast"}
+
+	labelNames := []string{"name", "address", "interface"}
+	c.descriptions = make(map[string]*prometheus.Desc)
+	for _, p := range c.props {
+		c.descriptions[p] = helper.DescriptionForPropertyName("ethernet", p, labelNames)
+	}
+
+	for _, size := range ethernetFrameSizes {
+		c.sizeProps = append(c.sizeProps, "rx-"+size, "tx-"+size)
+	}
+	c.rxFramesDesc = helper.Description("ethernet", "rx_frames", "number of frames received by frame size", append(labelNames, "size"))
+	c.txFramesDesc = helper.Description("ethernet", "tx_frames", "number of frames sent by frame size", append(labelNames, "size"))
+}
+
+func (c *ethernetStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range c.descriptions {
+		ch <- d
+	}
+	ch <- c.rxFramesDesc
+	ch <- c.txFramesDesc
+}
+
+func (c *ethernetStatsCollector) Collect(ctx *collector.Context) error {
+	props := append([]string{"name"}, c.props...)
+	props = append(props, c.sizeProps...)
+
+	reply, err := ctx.Client.Run("/interface/ethernet/print", "=stats=", "=.proplist="+strings.Join(props, ","))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching ethernet stats")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		c.collectForStat(re, ctx)
+	}
+
+	return nil
+}
+
+func (c *ethernetStatsCollector) collectForStat(re *proto.Sentence, ctx *collector.Context) {
+	iface := re.Map["name"]
+
+	for _, p := range c.props {
+		c.collectCounter(c.descriptions[p], p, re, ctx, iface)
+	}
+
+	for _, size := range ethernetFrameSizes {
+		c.collectCounter(c.rxFramesDesc, "rx-"+size, re, ctx, iface, size)
+		c.collectCounter(c.txFramesDesc, "tx-"+size, re, ctx, iface, size)
+	}
+}
+
+func (c *ethernetStatsCollector) collectCounter(desc *prometheus.Desc, property string, re *proto.Sentence, ctx *collector.Context, labelValues ...string) {
+	value := re.Map[property]
+	if value == "" {
+		return
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device":    ctx.Device.Name,
+			"interface": re.Map["name"],
+			"property":  property,
+			"value":     value,
+			"error":     err,
+		}).Error("error parsing ethernet stats metric value")
+		return
+	}
+
+	ctx.Ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v, append([]string{ctx.Device.Name, ctx.Device.Address}, labelValues...)...)
+}
@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/internal/collector"
+	"mikrotik-exporter/internal/config"
+	"mikrotik-exporter/internal/helper"
+)
+
+func init() {
+	Registry.Add("firewall", newFirewallCollector)
+}
+
+var firewallTables = []string{"filter", "nat", "mangle", "raw"}
+
+type firewallCollector struct {
+	commentRegex *regexp.Regexp
+	bytesDesc    *prometheus.Desc
+	packetsDesc  *prometheus.Desc
+}
+
+func newFirewallCollector() collector.Collector {
+	c := &firewallCollector{}
+	c.init()
+	return c
+}
+
+func (c *firewallCollector) init() {
+	const prefix = "firewall_rule"
+
+	labelNames := []string{"name", "address", "ip_version", "table", "rule_id", "chain", "action", "comment"}
+	c.bytesDesc = helper.Description(prefix, "bytes", "number of bytes matched by the rule", labelNames)
+	c.packetsDesc = helper.Description(prefix, "packets", "number of packets matched by the rule", labelNames)
+}
+
+func (c *firewallCollector) configure(cfg *config.Collectors) error {
+	if cfg.Firewall.CommentRegex == "" {
+		return nil
+	}
+
+	re, err := regexp.Compile(cfg.Firewall.CommentRegex)
+	if err != nil {
+		return err
+	}
+	c.commentRegex = re
+
+	return nil
+}
+
+func (c *firewallCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.bytesDesc
+	ch <- c.packetsDesc
+}
+
+func (c *firewallCollector) Collect(ctx *collector.Context) error {
+	version, err := ctx.MajorVersion()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching RouterOS version")
+		return err
+	}
+
+	for _, table := range firewallTables {
+		if err := c.collectTable("ip", "4", table, ctx); err != nil {
+			return err
+		}
+
+		// IPv6 NAT is only available since RouterOS 7
+		if table == "nat" && version < 7 {
+			continue
+		}
+
+		if err := c.collectTable("ipv6", "6", table, ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *firewallCollector) collectTable(topic, ipVersion, table string, ctx *collector.Context) error {
+	reply, err := ctx.Client.Run(fmt.Sprintf("/%s/firewall/%s/print", topic, table), "?disabled=false",
+		"=.proplist=.id,chain,action,comment,bytes,packets")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"topic":  topic,
+			"table":  table,
+			"error":  err,
+		}).Error("error fetching firewall rules")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		if c.commentRegex != nil && !c.commentRegex.MatchString(re.Map["comment"]) {
+			continue
+		}
+
+		c.collectForRule(ipVersion, table, re, ctx)
+	}
+
+	return nil
+}
+
+func (c *firewallCollector) collectForRule(ipVersion, table string, re *proto.Sentence, ctx *collector.Context) {
+	labelValues := []string{ctx.Device.Name, ctx.Device.Address, ipVersion, table,
+		re.Map[".id"], re.Map["chain"], re.Map["action"], re.Map["comment"]}
+
+	c.collectCounter(c.bytesDesc, "bytes", labelValues, re, ctx)
+	c.collectCounter(c.packetsDesc, "packets", labelValues, re, ctx)
+}
+
+func (c *firewallCollector) collectCounter(desc *prometheus.Desc, property string, labelValues []string, re *proto.Sentence, ctx *collector.Context) {
+	value := re.Map[property]
+	if value == "" {
+		return
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device":   ctx.Device.Name,
+			"rule":     re.Map[".id"],
+			"property": property,
+			"value":    value,
+			"error":    err,
+		}).Error("error parsing firewall rule metric value")
+		return
+	}
+
+	ctx.Ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v, labelValues...)
+}
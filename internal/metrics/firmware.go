@@ -1,12 +1,16 @@
 package metrics
 
 import (
+	"errors"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 
 	"mikrotik-exporter/internal/collector"
+	"mikrotik-exporter/internal/config"
 	"mikrotik-exporter/internal/helper"
 )
 
@@ -14,9 +18,26 @@ func init() {
 	Registry.Add("firmware", newFirmwareCollector)
 }
 
+const firmwareDefaultUpdateCheckInterval = 6 * time.Hour
+
+type firmwareUpdate struct {
+	channel   string
+	installed string
+	latest    string
+	checked   time.Time
+}
+
 type firmwareCollector struct {
-	props       []string
-	description *prometheus.Desc
+	props               []string
+	description         *prometheus.Desc
+	routerboardDesc     *prometheus.Desc
+	upgradePendingDesc  *prometheus.Desc
+	updateDesc          *prometheus.Desc
+	updateAvailableDesc *prometheus.Desc
+	updateCheck         bool
+	updateCheckInterval time.Duration
+	updatesMu           sync.Mutex
+	updates             map[string]*firmwareUpdate
 }
 
 func newFirmwareCollector() collector.Collector {
@@ -28,10 +49,35 @@ func newFirmwareCollector() collector.Collector {
 func (c *firmwareCollector) init() {
 	labelNames := []string{"devicename", "name", "disabled", "version", "build_time"}
 	c.description = helper.Description("system", "package", "system packages version", labelNames)
+
+	c.routerboardDesc = helper.Description("system_routerboard", "firmware_info", "RouterBOARD firmware versions",
+		[]string{"name", "address", "model", "firmware_type", "current_firmware", "upgrade_firmware"})
+	c.upgradePendingDesc = helper.Description("system_routerboard", "firmware_upgrade_pending", "RouterBOARD firmware differs from the firmware of the installed RouterOS (pending = 1)",
+		[]string{"name", "address"})
+	c.updateDesc = helper.Description("system_update", "info", "installed and latest RouterOS version of the update channel",
+		[]string{"name", "address", "channel", "installed_version", "latest_version"})
+	c.updateAvailableDesc = helper.Description("system_update", "available", "newer RouterOS version is available in the update channel (available = 1)",
+		[]string{"name", "address", "channel"})
+
+	c.updateCheckInterval = firmwareDefaultUpdateCheckInterval
+	c.updates = make(map[string]*firmwareUpdate)
+}
+
+func (c *firmwareCollector) configure(cfg *config.Collectors) error {
+	c.updateCheck = cfg.Firmware.UpdateCheck
+	if cfg.Firmware.UpdateCheckInterval > 0 {
+		c.updateCheckInterval = cfg.Firmware.UpdateCheckInterval
+	}
+
+	return nil
 }
 
 func (c *firmwareCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.description
+	ch <- c.routerboardDesc
+	ch <- c.upgradePendingDesc
+	ch <- c.updateDesc
+	ch <- c.updateAvailableDesc
 }
 
 func (c *firmwareCollector) Collect(ctx *collector.Context) error {
@@ -40,7 +86,7 @@ func (c *firmwareCollector) Collect(ctx *collector.Context) error {
 		log.WithFields(log.Fields{
 			"device": ctx.Device.Name,
 			"error":  err,
-		})
+		}).Error("error fetching packages")
 		return err
 	}
 
@@ -54,5 +100,119 @@ func (c *firmwareCollector) Collect(ctx *collector.Context) error {
 		ctx.Ch <- prometheus.MustNewConstMetric(c.description, prometheus.GaugeValue, v, ctx.Device.Name, pkg.Map["name"], pkg.Map["disabled"], pkg.Map["version"], pkg.Map["build-time"])
 	}
 
+	if err := c.collectRouterboard(ctx); err != nil {
+		return err
+	}
+
+	if c.updateCheck {
+		c.collectUpdate(ctx)
+	}
+
+	return nil
+}
+
+func (c *firmwareCollector) collectRouterboard(ctx *collector.Context) error {
+	reply, err := ctx.Client.Run("/system/routerboard/print", "=.proplist=routerboard,model,firmware-type,current-firmware,upgrade-firmware")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching routerboard firmware")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		// CHR and x86 installations have no RouterBOARD firmware
+		if re.Map["routerboard"] != "true" {
+			continue
+		}
+
+		ctx.Ch <- prometheus.MustNewConstMetric(c.routerboardDesc, prometheus.GaugeValue, 1, ctx.Device.Name, ctx.Device.Address,
+			re.Map["model"], re.Map["firmware-type"], re.Map["current-firmware"], re.Map["upgrade-firmware"])
+
+		pending := 0.0
+		if re.Map["current-firmware"] != re.Map["upgrade-firmware"] {
+			pending = 1
+		}
+		ctx.Ch <- prometheus.MustNewConstMetric(c.upgradePendingDesc, prometheus.GaugeValue, pending, ctx.Device.Name, ctx.Device.Address)
+	}
+
 	return nil
 }
+
+// collectUpdate reports the result of the last successful update check, the check makes the device
+// contact the MikroTik update servers so it only runs once per update check interval, even if it fails
+func (c *firmwareCollector) collectUpdate(ctx *collector.Context) {
+	c.updatesMu.Lock()
+	update := c.updates[ctx.Device.Name]
+	if update == nil {
+		update = &firmwareUpdate{}
+		c.updates[ctx.Device.Name] = update
+	}
+	c.updatesMu.Unlock()
+
+	// scrapes of a device are serialized, the update of a device is never used concurrently
+	if time.Since(update.checked) >= c.updateCheckInterval {
+		update.checked = time.Now()
+		if checked, err := c.checkForUpdates(ctx); err == nil {
+			update.channel, update.installed, update.latest = checked.channel, checked.installed, checked.latest
+		}
+	}
+
+	// no check has succeeded yet
+	if update.latest == "" {
+		return
+	}
+
+	ctx.Ch <- prometheus.MustNewConstMetric(c.updateDesc, prometheus.GaugeValue, 1, ctx.Device.Name, ctx.Device.Address,
+		update.channel, update.installed, update.latest)
+
+	available := 0.0
+	if update.latest != update.installed {
+		available = 1
+	}
+	ctx.Ch <- prometheus.MustNewConstMetric(c.updateAvailableDesc, prometheus.GaugeValue, available, ctx.Device.Name, ctx.Device.Address,
+		update.channel)
+}
+
+// checkForUpdates returns the update status, an error if the check did not return a latest version
+func (c *firmwareCollector) checkForUpdates(ctx *collector.Context) (*firmwareUpdate, error) {
+	if _, err := ctx.Client.Run("/system/package/update/check-for-updates", "=once="); err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error checking for updates")
+		return nil, err
+	}
+
+	reply, err := ctx.Client.Run("/system/package/update/print", "=.proplist=channel,installed-version,latest-version,status")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching update status")
+		return nil, err
+	}
+
+	update := &firmwareUpdate{}
+	if len(reply.Re) > 0 {
+		update.channel = reply.Re[0].Map["channel"]
+		update.installed = reply.Re[0].Map["installed-version"]
+		update.latest = reply.Re[0].Map["latest-version"]
+	}
+
+	// the check may still be running or the update servers were not reachable
+	if update.latest == "" {
+		err := errors.New("no latest version")
+		if len(reply.Re) > 0 && reply.Re[0].Map["status"] != "" {
+			err = errors.New(reply.Re[0].Map["status"])
+		}
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error checking for updates")
+		return nil, err
+	}
+
+	return update, nil
+}
@@ -0,0 +1,280 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/internal/collector"
+	"mikrotik-exporter/internal/config"
+	"mikrotik-exporter/internal/helper"
+)
+
+func init() {
+	Registry.Add("hotspot", newHotspotCollector)
+}
+
+// users change rarely, refetching them on every scrape is expensive with many vouchers
+const hotspotUsersRefreshInterval = 5 * time.Minute
+
+var hotspotUserCounters = []struct {
+	property string
+	name     string
+	help     string
+}{
+	{"bytes-in", "rx_bytes", "number of bytes received from the user"},
+	{"bytes-out", "tx_bytes", "number of bytes sent to the user"},
+	{"packets-in", "rx_packets", "number of packets received from the user"},
+	{"packets-out", "tx_packets", "number of packets sent to the user"},
+}
+
+type hotspotUsers struct {
+	profiles map[string]string
+	fetched  time.Time
+}
+
+type hotspotCollector struct {
+	maxUsers        int
+	activeUsersDesc *prometheus.Desc
+	hostsDesc       *prometheus.Desc
+	uptimeDesc      *prometheus.Desc
+	userDescs       map[string]*prometheus.Desc
+	usersMu         sync.Mutex
+	users           map[string]*hotspotUsers
+}
+
+func newHotspotCollector() collector.Collector {
+	c := &hotspotCollector{}
+	c.init()
+	return c
+}
+
+func (c *hotspotCollector) init() {
+	const prefix = "hotspot"
+
+	userLabelNames := []string{"name", "address", "server", "profile", "user", "user_address", "mac_address"}
+	c.activeUsersDesc = helper.Description(prefix, "active_users", "number of active users per server and user profile",
+		[]string{"name", "address", "server", "profile"})
+	c.hostsDesc = helper.Description(prefix, "hosts", "number of hosts per server",
+		[]string{"name", "address", "server", "authorized"})
+	c.uptimeDesc = helper.Description(prefix, "user_uptime_seconds", "time since the user logged in in seconds", userLabelNames)
+
+	c.userDescs = make(map[string]*prometheus.Desc)
+	for _, m := range hotspotUserCounters {
+		c.userDescs[m.property] = helper.Description(prefix, "user_"+m.name, m.help, userLabelNames)
+	}
+
+	c.users = make(map[string]*hotspotUsers)
+}
+
+func (c *hotspotCollector) configure(cfg *config.Collectors) error {
+	c.maxUsers = cfg.Hotspot.MaxUsers
+	return nil
+}
+
+func (c *hotspotCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.activeUsersDesc
+	ch <- c.hostsDesc
+	ch <- c.uptimeDesc
+	for _, d := range c.userDescs {
+		ch <- d
+	}
+}
+
+func (c *hotspotCollector) Collect(ctx *collector.Context) error {
+	servers, err := c.fetchServers(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, server := range servers {
+		for _, authorized := range []string{"true", "false"} {
+			if err := c.collectHostCount(server, authorized, ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	return c.collectActiveUsers(ctx)
+}
+
+func (c *hotspotCollector) fetchServers(ctx *collector.Context) ([]string, error) {
+	reply, err := ctx.Client.Run("/ip/hotspot/print", "=.proplist=name")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching hotspot servers")
+		return nil, err
+	}
+
+	servers := make([]string, 0, len(reply.Re))
+	for _, re := range reply.Re {
+		servers = append(servers, re.Map["name"])
+	}
+
+	return servers, nil
+}
+
+func (c *hotspotCollector) collectHostCount(server, authorized string, ctx *collector.Context) error {
+	reply, err := ctx.Client.Run("/ip/hotspot/host/print", fmt.Sprintf("?server=%s", server), fmt.Sprintf("?authorized=%s", authorized), "=count-only=")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"server": server,
+			"error":  err,
+		}).Error("error fetching hotspot host counts")
+		return err
+	}
+	if reply.Done.Map["ret"] == "" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(reply.Done.Map["ret"], 64)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"server": server,
+			"error":  err,
+		}).Error("error parsing hotspot host counts")
+		return err
+	}
+
+	ctx.Ch <- prometheus.MustNewConstMetric(c.hostsDesc, prometheus.GaugeValue, v, ctx.Device.Name, ctx.Device.Address, server, authorized)
+	return nil
+}
+
+// collectActiveUsers counts the active users per server and profile, the active users
+// do not carry their profile so it is looked up in the local users
+func (c *hotspotCollector) collectActiveUsers(ctx *collector.Context) error {
+	profiles, err := c.userProfiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	reply, err := ctx.Client.Run("/ip/hotspot/active/print",
+		"=.proplist=server,user,address,mac-address,uptime,bytes-in,bytes-out,packets-in,packets-out")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching hotspot active users")
+		return err
+	}
+
+	type userKey struct{ server, profile string }
+
+	var keys []userKey
+	counts := make(map[userKey]float64)
+	for _, re := range reply.Re {
+		key := userKey{re.Map["server"], profiles[re.Map["user"]]}
+		if _, exists := counts[key]; !exists {
+			keys = append(keys, key)
+		}
+		counts[key]++
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].server != keys[j].server {
+			return keys[i].server < keys[j].server
+		}
+		return keys[i].profile < keys[j].profile
+	})
+
+	for _, key := range keys {
+		ctx.Ch <- prometheus.MustNewConstMetric(c.activeUsersDesc, prometheus.GaugeValue, counts[key], ctx.Device.Name, ctx.Device.Address,
+			key.server, key.profile)
+	}
+
+	// export the same users on every scrape as long as they stay logged in
+	users := reply.Re
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Map["user"] != users[j].Map["user"] {
+			return users[i].Map["user"] < users[j].Map["user"]
+		}
+		return users[i].Map["mac-address"] < users[j].Map["mac-address"]
+	})
+	for i, re := range users {
+		if i >= c.maxUsers {
+			break
+		}
+		c.collectForUser(profiles[re.Map["user"]], re, ctx)
+	}
+
+	return nil
+}
+
+// userProfiles returns the profile of every local user, refetched once per refresh interval
+func (c *hotspotCollector) userProfiles(ctx *collector.Context) (map[string]string, error) {
+	c.usersMu.Lock()
+	users := c.users[ctx.Device.Name]
+	c.usersMu.Unlock()
+
+	if users != nil && time.Since(users.fetched) < hotspotUsersRefreshInterval {
+		return users.profiles, nil
+	}
+
+	reply, err := ctx.Client.Run("/ip/hotspot/user/print", "=.proplist=name,profile")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching hotspot users")
+		return nil, err
+	}
+
+	users = &hotspotUsers{profiles: make(map[string]string), fetched: time.Now()}
+	for _, re := range reply.Re {
+		users.profiles[re.Map["name"]] = re.Map["profile"]
+	}
+
+	c.usersMu.Lock()
+	c.users[ctx.Device.Name] = users
+	c.usersMu.Unlock()
+
+	return users.profiles, nil
+}
+
+func (c *hotspotCollector) collectForUser(profile string, re *proto.Sentence, ctx *collector.Context) {
+	labelValues := []string{ctx.Device.Name, ctx.Device.Address, re.Map["server"], profile, re.Map["user"], re.Map["address"], re.Map["mac-address"]}
+
+	if value := re.Map["uptime"]; value != "" {
+		v, err := helper.ParseDuration(value)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"device":   ctx.Device.Name,
+				"user":     re.Map["user"],
+				"property": "uptime",
+				"value":    value,
+				"error":    err,
+			}).Error("error parsing hotspot user metric value")
+		} else {
+			ctx.Ch <- prometheus.MustNewConstMetric(c.uptimeDesc, prometheus.GaugeValue, v, labelValues...)
+		}
+	}
+
+	for _, m := range hotspotUserCounters {
+		value := re.Map[m.property]
+		if value == "" {
+			continue
+		}
+
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"device":   ctx.Device.Name,
+				"user":     re.Map["user"],
+				"property": m.property,
+				"value":    value,
+				"error":    err,
+			}).Error("error parsing hotspot user metric value")
+			continue
+		}
+
+		ctx.Ch <- prometheus.MustNewConstMetric(c.userDescs[m.property], prometheus.CounterValue, v, labelValues...)
+	}
+}
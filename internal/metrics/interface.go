@@ -70,6 +70,12 @@ func (c *interfaceCollector) fetch(ctx *collector.Context) ([]*proto.Sentence, e
 }
 
 func (c *interfaceCollector) collectForStat(re *proto.Sentence, ctx *collector.Context) {
+	state := "down"
+	if re.Map["running"] == "true" {
+		state = "up"
+	}
+	ctx.ObserveState("interface", re.Map["name"], state)
+
 	for _, p := range c.props[5:] {
 		c.collectMetricForProperty(p, re, ctx)
 	}
@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/internal/collector"
+	"mikrotik-exporter/internal/config"
+	"mikrotik-exporter/internal/helper"
+)
+
+func init() {
+	Registry.Add("interface_rate", newInterfaceRateCollector)
+}
+
+type interfaceRateCollector struct {
+	props        []string
+	nameRegex    *regexp.Regexp
+	descriptions map[string]*prometheus.Desc
+}
+
+func newInterfaceRateCollector() collector.Collector {
+	c := &interfaceRateCollector{}
+	c.init()
+	return c
+}
+
+func (c *interfaceRateCollector) init() {
+	// fp-* are the fast-path rates, which are not reported by every interface type
+	c.props = []string{"name", "rx-bits-per-second", "tx-bits-per-second", "rx-packets-per-second", "tx-packets-per-second",
+		"fp-rx-bits-per-second", "fp-tx-bits-per-second", "fp-rx-packets-per-second", "fp-tx-packets-per-second"}
+
+	labelNames := []string{"name", "address", "interface"}
+	c.descriptions = make(map[string]*prometheus.Desc)
+	for _, p := range c.props[1:] {
+		c.descriptions[p] = helper.DescriptionForPropertyName("interface_rate", p, labelNames)
+	}
+}
+
+func (c *interfaceRateCollector) configure(cfg *config.Collectors) error {
+	if cfg.InterfaceRate.NameRegex == "" {
+		return nil
+	}
+
+	re, err := regexp.Compile(cfg.InterfaceRate.NameRegex)
+	if err != nil {
+		return err
+	}
+	c.nameRegex = re
+
+	return nil
+}
+
+func (c *interfaceRateCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range c.descriptions {
+		ch <- d
+	}
+}
+
+func (c *interfaceRateCollector) Collect(ctx *collector.Context) error {
+	names, err := c.fetchInterfaceNames(ctx)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	reply, err := ctx.Client.Run("/interface/monitor-traffic", "=interface="+strings.Join(names, ","), "=once=")
+	if err != nil {
+		// an interface which went down since it was listed fails the whole request
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Debug("error fetching interface rates, monitoring interfaces one by one")
+
+		for _, name := range names {
+			c.collectForInterface(name, ctx)
+		}
+		return nil
+	}
+
+	for _, re := range reply.Re {
+		for _, p := range c.props[1:] {
+			c.collectMetricForProperty(p, re, ctx)
+		}
+	}
+
+	return nil
+}
+
+// collectForInterface only logs errors, a failing interface must not fail the scrape of the device
+func (c *interfaceRateCollector) collectForInterface(name string, ctx *collector.Context) {
+	reply, err := ctx.Client.Run("/interface/monitor-traffic", "=interface="+name, "=once=")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device":    ctx.Device.Name,
+			"interface": name,
+			"error":     err,
+		}).Error("error fetching interface rates")
+		return
+	}
+
+	for _, re := range reply.Re {
+		for _, p := range c.props[1:] {
+			c.collectMetricForProperty(p, re, ctx)
+		}
+	}
+}
+
+// fetchInterfaceNames returns the running interfaces matching the name regex, all running interfaces without regex
+func (c *interfaceRateCollector) fetchInterfaceNames(ctx *collector.Context) ([]string, error) {
+	reply, err := ctx.Client.Run("/interface/print", "?disabled=false", "?running=true", "=.proplist=name")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching interfaces")
+		return nil, err
+	}
+
+	var names []string
+	for _, re := range reply.Re {
+		name := re.Map["name"]
+		if c.nameRegex != nil && !c.nameRegex.MatchString(name) {
+			continue
+		}
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+func (c *interfaceRateCollector) collectMetricForProperty(property string, re *proto.Sentence, ctx *collector.Context) {
+	value := re.Map[property]
+	if value == "" {
+		return
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device":    ctx.Device.Name,
+			"interface": re.Map["name"],
+			"property":  property,
+			"value":     value,
+			"error":     err,
+		}).Error("error parsing interface rate metric value")
+		return
+	}
+
+	ctx.Ch <- prometheus.MustNewConstMetric(c.descriptions[property], prometheus.GaugeValue, v, ctx.Device.Name, ctx.Device.Address, re.Map["name"])
+}
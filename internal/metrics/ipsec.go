@@ -72,6 +72,7 @@ func (c *ipsecCollector) fetch(ctx *collector.Context) ([]*proto.Sentence, error
 func (c *ipsecCollector) collectForStat(re *proto.Sentence, ctx *collector.Context) {
 	srcdst := re.Map["src-address"] + "-" + re.Map["dst-address"]
 	comment := re.Map["comment"]
+	ctx.ObserveState("ipsec", srcdst, re.Map["ph2-state"])
 
 	for _, p := range c.props[2:] {
 		c.collectMetricForProperty(p, srcdst, comment, re, ctx)
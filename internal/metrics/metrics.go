@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"mikrotik-exporter/internal/collector"
+	"mikrotik-exporter/internal/config"
 )
 
 var Registry = &registry{
@@ -13,6 +14,11 @@ var Registry = &registry{
 
 type initialize func() collector.Collector
 
+// configurable is implemented by collectors with settings in the config file
+type configurable interface {
+	configure(cfg *config.Collectors) error
+}
+
 type registry struct {
 	features map[string]initialize
 }
@@ -25,12 +31,18 @@ func (r *registry) Add(name string, init initialize) {
 	r.features[name] = init
 }
 
-func (r *registry) Load(feats ...string) ([]collector.Collector, error) {
+func (r *registry) Load(cfg *config.Collectors, feats ...string) ([]collector.Collector, error) {
 	var cs []collector.Collector
 
 	for _, feat := range feats {
 		if init, exists := r.features[feat]; exists {
-			cs = append(cs, init())
+			c := init()
+			if cc, ok := c.(configurable); ok {
+				if err := cc.configure(cfg); err != nil {
+					return nil, fmt.Errorf("invalid settings for %s: %w", feat, err)
+				}
+			}
+			cs = append(cs, c)
 		} else {
 			return nil, errors.New(fmt.Sprintf("no collector for %s", feat))
 		}
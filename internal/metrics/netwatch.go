@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -16,9 +17,26 @@ func init() {
 	Registry.Add("netwatch", newNetwatchCollector)
 }
 
+// netwatchProbeMetrics are the statistics RouterOS 7 reports for icmp, tcp-conn and http-get probes
+var netwatchProbeMetrics = []struct {
+	property string
+	name     string
+	help     string
+	duration bool
+}{
+	{"rtt-avg", "rtt_avg_seconds", "average round trip time of the probe in seconds", true},
+	{"rtt-min", "rtt_min_seconds", "minimum round trip time of the probe in seconds", true},
+	{"rtt-max", "rtt_max_seconds", "maximum round trip time of the probe in seconds", true},
+	{"rtt-jitter", "rtt_jitter_seconds", "round trip time jitter of the probe in seconds", true},
+	{"loss-percent", "loss_percent", "packet loss of the probe in percent", false},
+	{"http-status-code", "http_status_code", "HTTP status code returned to the probe", false},
+}
+
 type netwatchCollector struct {
 	props        []string
+	probeProps   []string
 	descriptions map[string]*prometheus.Desc
+	probeDescs   map[string]*prometheus.Desc
 }
 
 func newNetwatchCollector() collector.Collector {
@@ -29,34 +47,65 @@ func newNetwatchCollector() collector.Collector {
 
 func (c *netwatchCollector) init() {
 	c.props = []string{"host", "comment", "status"}
-	labelNames := []string{"name", "address", "host", "comment"}
+	// type and probe tell apart the probes of a host on RouterOS 7, they are empty on RouterOS 6
+	// and Prometheus drops empty labels, so the RouterOS 6 series are unchanged
+	labelNames := []string{"name", "address", "host", "comment", "type", "probe"}
 	c.descriptions = make(map[string]*prometheus.Desc)
 	for _, p := range c.props[1:] {
 		c.descriptions[p] = helper.DescriptionForPropertyName("netwatch", p, labelNames)
 	}
+
+	c.probeProps = append([]string{"name", "type"}, c.props...)
+	probeLabelNames := []string{"name", "address", "type", "probe", "host"}
+	c.probeDescs = make(map[string]*prometheus.Desc)
+	for _, m := range netwatchProbeMetrics {
+		c.probeProps = append(c.probeProps, m.property)
+		c.probeDescs[m.property] = helper.Description("netwatch", m.name, m.help, probeLabelNames)
+	}
 }
 
 func (c *netwatchCollector) Describe(ch chan<- *prometheus.Desc) {
 	for _, d := range c.descriptions {
 		ch <- d
 	}
+	for _, d := range c.probeDescs {
+		ch <- d
+	}
 }
 
 func (c *netwatchCollector) Collect(ctx *collector.Context) error {
-	stats, err := c.fetch(ctx)
+	version, err := ctx.MajorVersion()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching RouterOS version")
+		return err
+	}
+
+	// probe types and their statistics were added in RouterOS 7
+	props := c.props
+	if version >= 7 {
+		props = c.probeProps
+	}
+
+	stats, err := c.fetch(props, ctx)
 	if err != nil {
 		return err
 	}
 
 	for _, re := range stats {
-		c.collectForStat(re, ctx)
+		c.collectForStat(version, re, ctx)
+		if version >= 7 {
+			c.collectProbeStats(re, ctx)
+		}
 	}
 
 	return nil
 }
 
-func (c *netwatchCollector) fetch(ctx *collector.Context) ([]*proto.Sentence, error) {
-	reply, err := ctx.Client.Run("/tool/netwatch/print", "?disabled=false", "=.proplist="+strings.Join(c.props, ","))
+func (c *netwatchCollector) fetch(props []string, ctx *collector.Context) ([]*proto.Sentence, error) {
+	reply, err := ctx.Client.Run("/tool/netwatch/print", "?disabled=false", "=.proplist="+strings.Join(props, ","))
 	if err != nil {
 		log.WithFields(log.Fields{
 			"device": ctx.Device.Name,
@@ -68,16 +117,22 @@ func (c *netwatchCollector) fetch(ctx *collector.Context) ([]*proto.Sentence, er
 	return reply.Re, nil
 }
 
-func (c *netwatchCollector) collectForStat(re *proto.Sentence, ctx *collector.Context) {
+func (c *netwatchCollector) collectForStat(version int, re *proto.Sentence, ctx *collector.Context) {
 	host := re.Map["host"]
-	comment := re.Map["comment"]
+
+	// a host can be watched by several probes on RouterOS 7
+	object := host
+	if version >= 7 {
+		object = fmt.Sprintf("%s/%s/%s", host, re.Map["type"], re.Map["name"])
+	}
+	ctx.ObserveState("netwatch", object, re.Map["status"])
 
 	for _, p := range c.props[2:] {
-		c.collectMetricForProperty(p, host, comment, re, ctx)
+		c.collectMetricForProperty(p, host, re, ctx)
 	}
 }
 
-func (c *netwatchCollector) collectMetricForProperty(property, host, comment string, re *proto.Sentence, ctx *collector.Context) {
+func (c *netwatchCollector) collectMetricForProperty(property, host string, re *proto.Sentence, ctx *collector.Context) {
 	desc := c.descriptions[property]
 	if value := re.Map[property]; value != "" {
 		var numericValue float64
@@ -97,6 +152,39 @@ func (c *netwatchCollector) collectMetricForProperty(property, host, comment str
 				"error":    fmt.Errorf("unexpected netwatch status value"),
 			}).Error("error parsing netwatch metric value")
 		}
-		ctx.Ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, numericValue, ctx.Device.Name, ctx.Device.Address, host, comment)
+		ctx.Ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, numericValue, ctx.Device.Name, ctx.Device.Address, host,
+			re.Map["comment"], re.Map["type"], re.Map["name"])
+	}
+}
+
+func (c *netwatchCollector) collectProbeStats(re *proto.Sentence, ctx *collector.Context) {
+	for _, m := range netwatchProbeMetrics {
+		value := re.Map[m.property]
+		if value == "" {
+			continue
+		}
+
+		var (
+			v   float64
+			err error
+		)
+		if m.duration {
+			v, err = helper.ParseRTT(value)
+		} else {
+			v, err = strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		}
+		if err != nil {
+			log.WithFields(log.Fields{
+				"device":   ctx.Device.Name,
+				"host":     re.Map["host"],
+				"property": m.property,
+				"value":    value,
+				"error":    err,
+			}).Error("error parsing netwatch metric value")
+			continue
+		}
+
+		ctx.Ch <- prometheus.MustNewConstMetric(c.probeDescs[m.property], prometheus.GaugeValue, v, ctx.Device.Name, ctx.Device.Address,
+			re.Map["type"], re.Map["name"], re.Map["host"])
 	}
 }
@@ -0,0 +1,240 @@
+package metrics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/internal/collector"
+	"mikrotik-exporter/internal/helper"
+)
+
+func init() {
+	Registry.Add("ospf", newOSPFCollector)
+}
+
+// ospfNeighborStates maps neighbor states to the values of ospfNbrState in the OSPF MIB
+var ospfNeighborStates = map[string]float64{
+	"down":     1,
+	"attempt":  2,
+	"init":     3,
+	"2-way":    4,
+	"exstart":  5,
+	"exchange": 6,
+	"loading":  7,
+	"full":     8,
+}
+
+type ospfCollector struct {
+	instanceDesc          *prometheus.Desc
+	areaDesc              *prometheus.Desc
+	areaLSACountDesc      *prometheus.Desc
+	neighborStateDesc     *prometheus.Desc
+	neighborUpDesc        *prometheus.Desc
+	neighborChangesDesc   *prometheus.Desc
+	neighborAdjacencyDesc *prometheus.Desc
+}
+
+func newOSPFCollector() collector.Collector {
+	c := &ospfCollector{}
+	c.init()
+	return c
+}
+
+func (c *ospfCollector) init() {
+	const prefix = "ospf"
+
+	labelNames := []string{"name", "address", "instance"}
+	neighborLabelNames := []string{"name", "address", "instance", "area", "router_id", "neighbor_address", "interface"}
+	c.instanceDesc = helper.Description(prefix, "instance_info", "OSPF instance with its router id", append(labelNames, "router_id", "version"))
+	c.areaDesc = helper.Description(prefix, "area_info", "OSPF area of an instance", append(labelNames, "area", "area_id", "type"))
+	c.areaLSACountDesc = helper.Description(prefix, "area_lsa_count", "number of LSAs in the database of an area", append(labelNames, "area"))
+	c.neighborStateDesc = helper.Description(prefix, "neighbor_state", "neighbor state (1 = down, 2 = attempt, 3 = init, 4 = 2-way, 5 = exstart, 6 = exchange, 7 = loading, 8 = full)", neighborLabelNames)
+	c.neighborUpDesc = helper.Description(prefix, "neighbor_up", "adjacency with neighbor is fully established (up = 1)", neighborLabelNames)
+	c.neighborChangesDesc = helper.Description(prefix, "neighbor_state_changes", "number of state changes of neighbor", neighborLabelNames)
+	c.neighborAdjacencyDesc = helper.Description(prefix, "neighbor_adjacency_seconds", "time since adjacency with neighbor was established in seconds", neighborLabelNames)
+}
+
+func (c *ospfCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.instanceDesc
+	ch <- c.areaDesc
+	ch <- c.areaLSACountDesc
+	ch <- c.neighborStateDesc
+	ch <- c.neighborUpDesc
+	ch <- c.neighborChangesDesc
+	ch <- c.neighborAdjacencyDesc
+}
+
+func (c *ospfCollector) Collect(ctx *collector.Context) error {
+	version, err := ctx.MajorVersion()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching RouterOS version")
+		return err
+	}
+
+	if err := c.collectInstances(version, ctx); err != nil {
+		return err
+	}
+
+	if err := c.collectAreas(ctx); err != nil {
+		return err
+	}
+
+	return c.collectNeighbors(version, ctx)
+}
+
+func (c *ospfCollector) collectInstances(version int, ctx *collector.Context) error {
+	reply, err := ctx.Client.Run("/routing/ospf/instance/print", "?disabled=false", "=.proplist=name,router-id,version")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching ospf instances")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		// RouterOS 6 handles OSPFv3 in a separate menu
+		ospfVersion := re.Map["version"]
+		if version < 7 || ospfVersion == "" {
+			ospfVersion = "2"
+		}
+
+		ctx.Ch <- prometheus.MustNewConstMetric(c.instanceDesc, prometheus.GaugeValue, 1, ctx.Device.Name, ctx.Device.Address,
+			re.Map["name"], re.Map["router-id"], ospfVersion)
+	}
+
+	return nil
+}
+
+func (c *ospfCollector) collectAreas(ctx *collector.Context) error {
+	reply, err := ctx.Client.Run("/routing/ospf/area/print", "?disabled=false", "=.proplist=name,instance,area-id,type")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching ospf areas")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		ctx.Ch <- prometheus.MustNewConstMetric(c.areaDesc, prometheus.GaugeValue, 1, ctx.Device.Name, ctx.Device.Address,
+			re.Map["instance"], re.Map["name"], re.Map["area-id"], re.Map["type"])
+
+		if err := c.collectLSACount(re.Map["instance"], re.Map["name"], ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *ospfCollector) collectLSACount(instance, area string, ctx *collector.Context) error {
+	reply, err := ctx.Client.Run("/routing/ospf/lsa/print", fmt.Sprintf("?area=%s", area), "=count-only=")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"area":   area,
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching ospf lsa counts")
+		return err
+	}
+	if reply.Done.Map["ret"] == "" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(reply.Done.Map["ret"], 64)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"area":   area,
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error parsing ospf lsa counts")
+		return err
+	}
+
+	ctx.Ch <- prometheus.MustNewConstMetric(c.areaLSACountDesc, prometheus.GaugeValue, v, ctx.Device.Name, ctx.Device.Address, instance, area)
+	return nil
+}
+
+func (c *ospfCollector) collectNeighbors(version int, ctx *collector.Context) error {
+	// RouterOS 7 reports the area of a neighbor, RouterOS 6 only the interface
+	props := "instance,router-id,address,interface,state,state-changes,adjacency"
+	if version >= 7 {
+		props = "instance,area,router-id,address,state,state-changes,adjacency"
+	}
+
+	reply, err := ctx.Client.Run("/routing/ospf/neighbor/print", "=.proplist="+props)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching ospf neighbors")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		c.collectForNeighbor(re, ctx)
+	}
+
+	return nil
+}
+
+func (c *ospfCollector) collectForNeighbor(re *proto.Sentence, ctx *collector.Context) {
+	labelValues := []string{ctx.Device.Name, ctx.Device.Address, re.Map["instance"], re.Map["area"], re.Map["router-id"], re.Map["address"], re.Map["interface"]}
+
+	state := strings.ToLower(re.Map["state"])
+	if v, ok := ospfNeighborStates[state]; ok {
+		ctx.Ch <- prometheus.MustNewConstMetric(c.neighborStateDesc, prometheus.GaugeValue, v, labelValues...)
+	} else {
+		log.WithFields(log.Fields{
+			"device":   ctx.Device.Name,
+			"neighbor": re.Map["address"],
+			"property": "state",
+			"value":    re.Map["state"],
+			"error":    fmt.Errorf("unexpected ospf neighbor state"),
+		}).Error("error parsing ospf neighbor metric value")
+	}
+
+	up := 0.0
+	if state == "full" {
+		up = 1
+	}
+	ctx.Ch <- prometheus.MustNewConstMetric(c.neighborUpDesc, prometheus.GaugeValue, up, labelValues...)
+
+	if value := re.Map["state-changes"]; value != "" {
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"device":   ctx.Device.Name,
+				"neighbor": re.Map["address"],
+				"property": "state-changes",
+				"value":    value,
+				"error":    err,
+			}).Error("error parsing ospf neighbor metric value")
+		} else {
+			ctx.Ch <- prometheus.MustNewConstMetric(c.neighborChangesDesc, prometheus.CounterValue, v, labelValues...)
+		}
+	}
+
+	if value := re.Map["adjacency"]; value != "" {
+		v, err := helper.ParseDuration(value)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"device":   ctx.Device.Name,
+				"neighbor": re.Map["address"],
+				"property": "adjacency",
+				"value":    value,
+				"error":    err,
+			}).Error("error parsing ospf neighbor metric value")
+		} else {
+			ctx.Ch <- prometheus.MustNewConstMetric(c.neighborAdjacencyDesc, prometheus.GaugeValue, v, labelValues...)
+		}
+	}
+}
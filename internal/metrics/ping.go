@@ -0,0 +1,223 @@
+package metrics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/internal/collector"
+	"mikrotik-exporter/internal/config"
+	"mikrotik-exporter/internal/helper"
+)
+
+func init() {
+	Registry.Add("ping", newPingCollector)
+}
+
+const (
+	pingDefaultCount    = 3
+	pingDefaultInterval = 200 * time.Millisecond
+)
+
+type pingCollector struct {
+	count         int
+	size          int
+	interval      time.Duration
+	traceroute    bool
+	targets       []*config.PingTarget
+	sentDesc      *prometheus.Desc
+	receivedDesc  *prometheus.Desc
+	lossDesc      *prometheus.Desc
+	rttMinDesc    *prometheus.Desc
+	rttAvgDesc    *prometheus.Desc
+	rttMaxDesc    *prometheus.Desc
+	hopLossDesc   *prometheus.Desc
+	hopRTTAvgDesc *prometheus.Desc
+}
+
+func newPingCollector() collector.Collector {
+	c := &pingCollector{}
+	c.init()
+	return c
+}
+
+func (c *pingCollector) init() {
+	const prefix = "ping"
+
+	labelNames := []string{"name", "address", "target", "target_address"}
+	c.sentDesc = helper.Description(prefix, "sent", "number of echo requests sent to the target", labelNames)
+	c.receivedDesc = helper.Description(prefix, "received", "number of echo replies received from the target", labelNames)
+	c.lossDesc = helper.Description(prefix, "loss_percent", "packet loss to the target in percent", labelNames)
+	c.rttMinDesc = helper.Description(prefix, "rtt_min_seconds", "minimum round trip time to the target in seconds", labelNames)
+	c.rttAvgDesc = helper.Description(prefix, "rtt_avg_seconds", "average round trip time to the target in seconds", labelNames)
+	c.rttMaxDesc = helper.Description(prefix, "rtt_max_seconds", "maximum round trip time to the target in seconds", labelNames)
+
+	hopLabelNames := []string{"name", "address", "target", "target_address", "hop", "hop_address"}
+	c.hopLossDesc = helper.Description("traceroute", "hop_loss_percent", "packet loss to the hop in percent", hopLabelNames)
+	c.hopRTTAvgDesc = helper.Description("traceroute", "hop_rtt_avg_seconds", "average round trip time to the hop in seconds", hopLabelNames)
+
+	c.count = pingDefaultCount
+	c.interval = pingDefaultInterval
+}
+
+func (c *pingCollector) configure(cfg *config.Collectors) error {
+	for _, t := range cfg.Ping.Targets {
+		if t.Address == "" {
+			return fmt.Errorf("ping target %q has no address", t.Name)
+		}
+	}
+
+	c.targets = cfg.Ping.Targets
+	c.size = cfg.Ping.Size
+	c.traceroute = cfg.Ping.Traceroute
+	if cfg.Ping.Count > 0 {
+		c.count = cfg.Ping.Count
+	}
+	if cfg.Ping.Interval > 0 {
+		c.interval = cfg.Ping.Interval
+	}
+
+	return nil
+}
+
+func (c *pingCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.sentDesc
+	ch <- c.receivedDesc
+	ch <- c.lossDesc
+	ch <- c.rttMinDesc
+	ch <- c.rttAvgDesc
+	ch <- c.rttMaxDesc
+	ch <- c.hopLossDesc
+	ch <- c.hopRTTAvgDesc
+}
+
+func (c *pingCollector) Collect(ctx *collector.Context) error {
+	for _, t := range c.targets {
+		if !t.AppliesTo(ctx.Device) {
+			continue
+		}
+
+		c.collectPing(t, ctx)
+
+		if c.traceroute {
+			c.collectTraceroute(t, ctx)
+		}
+	}
+
+	return nil
+}
+
+// collectPing only logs errors, an unreachable target must not fail the scrape of the device
+func (c *pingCollector) collectPing(t *config.PingTarget, ctx *collector.Context) {
+	sentence := []string{
+		"/ping",
+		fmt.Sprintf("=address=%s", t.Address),
+		fmt.Sprintf("=count=%d", c.count),
+		fmt.Sprintf("=interval=%dms", c.interval.Milliseconds()),
+	}
+	if c.size > 0 {
+		sentence = append(sentence, fmt.Sprintf("=size=%d", c.size))
+	}
+
+	reply, err := ctx.Client.Run(sentence...)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"target": t.Address,
+			"error":  err,
+		}).Error("error pinging target")
+		return
+	}
+	if len(reply.Re) == 0 {
+		return
+	}
+
+	// every reply carries the statistics so far, the last one covers all echo requests
+	re := reply.Re[len(reply.Re)-1]
+	labelValues := []string{ctx.Device.Name, ctx.Device.Address, t.Name, t.Address}
+
+	c.collectValue(c.sentDesc, "sent", nil, t, labelValues, re, ctx)
+	c.collectValue(c.receivedDesc, "received", nil, t, labelValues, re, ctx)
+	c.collectValue(c.lossDesc, "packet-loss", nil, t, labelValues, re, ctx)
+	c.collectValue(c.rttMinDesc, "min-rtt", helper.ParseRTT, t, labelValues, re, ctx)
+	c.collectValue(c.rttAvgDesc, "avg-rtt", helper.ParseRTT, t, labelValues, re, ctx)
+	c.collectValue(c.rttMaxDesc, "max-rtt", helper.ParseRTT, t, labelValues, re, ctx)
+}
+
+func (c *pingCollector) collectTraceroute(t *config.PingTarget, ctx *collector.Context) {
+	reply, err := ctx.Client.Run("/tool/traceroute", fmt.Sprintf("=address=%s", t.Address), fmt.Sprintf("=count=%d", c.count))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"target": t.Address,
+			"error":  err,
+		}).Error("error tracing route to target")
+		return
+	}
+
+	// traceroute repeats the hop list once per round, the last section has the final statistics
+	var hops []*proto.Sentence
+	section := ""
+	for _, re := range reply.Re {
+		if re.Map[".section"] != section {
+			section = re.Map[".section"]
+			hops = nil
+		}
+		hops = append(hops, re)
+	}
+
+	for i, re := range hops {
+		labelValues := []string{ctx.Device.Name, ctx.Device.Address, t.Name, t.Address, strconv.Itoa(i + 1), re.Map["address"]}
+
+		c.collectValue(c.hopLossDesc, "loss", parsePercent, t, labelValues, re, ctx)
+		c.collectValue(c.hopRTTAvgDesc, "avg", parseMilliseconds, t, labelValues, re, ctx)
+	}
+}
+
+// collectValue parses the value with parse, or as plain number if parse is nil
+func (c *pingCollector) collectValue(desc *prometheus.Desc, property string, parse func(string) (float64, error),
+	t *config.PingTarget, labelValues []string, re *proto.Sentence, ctx *collector.Context) {
+	value := re.Map[property]
+	if value == "" {
+		return
+	}
+
+	var v float64
+	var err error
+	if parse == nil {
+		v, err = strconv.ParseFloat(value, 64)
+	} else {
+		v, err = parse(value)
+	}
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device":   ctx.Device.Name,
+			"target":   t.Address,
+			"property": property,
+			"value":    value,
+			"error":    err,
+		}).Error("error parsing ping metric value")
+		return
+	}
+
+	ctx.Ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, labelValues...)
+}
+
+func parsePercent(value string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+}
+
+// parseMilliseconds parses traceroute times, which are printed in milliseconds without unit
+func parseMilliseconds(value string) (float64, error) {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return helper.ParseRTT(value)
+	}
+
+	return v / 1000, nil
+}
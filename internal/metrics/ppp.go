@@ -0,0 +1,337 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/internal/collector"
+	"mikrotik-exporter/internal/config"
+	"mikrotik-exporter/internal/helper"
+)
+
+func init() {
+	Registry.Add("ppp", newPPPCollector)
+}
+
+const (
+	pppDefaultMaxUsers = 100
+	// secrets change rarely, refetching them on every scrape is expensive on large BNGs
+	pppSecretsRefreshInterval = 5 * time.Minute
+)
+
+var pppServices = []string{"pppoe", "l2tp", "sstp", "ovpn", "pptp"}
+
+type pppSecrets struct {
+	profiles map[string]string
+	fetched  time.Time
+}
+
+type pppCollector struct {
+	users               []string
+	sessionsDesc        *prometheus.Desc
+	radiusSessionsDesc  *prometheus.Desc
+	profileSessionsDesc *prometheus.Desc
+	uptimeDesc          *prometheus.Desc
+	rxBytesDesc         *prometheus.Desc
+	txBytesDesc         *prometheus.Desc
+	secretsMu           sync.Mutex
+	secrets             map[string]*pppSecrets
+}
+
+func newPPPCollector() collector.Collector {
+	c := &pppCollector{}
+	c.init()
+	return c
+}
+
+func (c *pppCollector) init() {
+	const prefix = "ppp"
+
+	sessionLabelNames := []string{"name", "address", "user", "service", "caller_id", "session_address"}
+	interfaceLabelNames := []string{"name", "address", "user", "service", "interface"}
+
+	c.sessionsDesc = helper.Description(prefix, "active_sessions", "number of active sessions per service", []string{"name", "address", "service"})
+	c.radiusSessionsDesc = helper.Description(prefix, "radius_active_sessions", "number of active sessions authenticated by RADIUS", []string{"name", "address"})
+	c.profileSessionsDesc = helper.Description(prefix, "profile_active_sessions", "number of active sessions of local secrets per profile", []string{"name", "address", "profile"})
+	c.uptimeDesc = helper.Description(prefix, "session_uptime_seconds", "uptime of the session of a user in seconds", sessionLabelNames)
+	c.rxBytesDesc = helper.Description(prefix, "session_rx_bytes", "number of bytes received on the interface of a user session", interfaceLabelNames)
+	c.txBytesDesc = helper.Description(prefix, "session_tx_bytes", "number of bytes sent on the interface of a user session", interfaceLabelNames)
+
+	c.secrets = make(map[string]*pppSecrets)
+}
+
+func (c *pppCollector) configure(cfg *config.Collectors) error {
+	maxUsers := cfg.PPP.MaxUsers
+	if maxUsers <= 0 {
+		maxUsers = pppDefaultMaxUsers
+	}
+
+	c.users = cfg.PPP.Users
+	if len(c.users) > maxUsers {
+		log.WithFields(log.Fields{
+			"users":     len(c.users),
+			"max_users": maxUsers,
+		}).Warn("too many ppp users configured, only the first are exported")
+		c.users = c.users[:maxUsers]
+	}
+
+	return nil
+}
+
+func (c *pppCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.sessionsDesc
+	ch <- c.radiusSessionsDesc
+	ch <- c.profileSessionsDesc
+	ch <- c.uptimeDesc
+	ch <- c.rxBytesDesc
+	ch <- c.txBytesDesc
+}
+
+func (c *pppCollector) Collect(ctx *collector.Context) error {
+	for _, service := range pppServices {
+		if err := c.collectCount(c.sessionsDesc, fmt.Sprintf("?service=%s", service), ctx, service); err != nil {
+			return err
+		}
+	}
+
+	if err := c.collectCount(c.radiusSessionsDesc, "?radius=true", ctx); err != nil {
+		return err
+	}
+
+	if err := c.collectProfileCounts(ctx); err != nil {
+		return err
+	}
+
+	for _, user := range c.users {
+		if err := c.collectForUser(user, ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *pppCollector) collectCount(desc *prometheus.Desc, query string, ctx *collector.Context, labelValues ...string) error {
+	reply, err := ctx.Client.Run("/ppp/active/print", query, "=count-only=")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"query":  query,
+			"error":  err,
+		}).Error("error fetching ppp session counts")
+		return err
+	}
+	if reply.Done.Map["ret"] == "" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(reply.Done.Map["ret"], 64)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"query":  query,
+			"error":  err,
+		}).Error("error parsing ppp session counts")
+		return err
+	}
+
+	ctx.Ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, append([]string{ctx.Device.Name, ctx.Device.Address}, labelValues...)...)
+	return nil
+}
+
+// collectProfileCounts maps the sessions of local secrets to the profiles of the secrets,
+// the active sessions do not carry the profile themselves
+func (c *pppCollector) collectProfileCounts(ctx *collector.Context) error {
+	profiles, err := c.secretProfiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	reply, err := ctx.Client.Run("/ppp/active/print", "?radius=false", "=.proplist=name")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching ppp sessions")
+		return err
+	}
+
+	counts := make(map[string]float64)
+	for _, re := range reply.Re {
+		// the secret may have been added or removed since the secrets were fetched
+		profile, found := profiles[re.Map["name"]]
+		if !found {
+			continue
+		}
+		counts[profile]++
+	}
+
+	names := make([]string, 0, len(counts))
+	for profile := range counts {
+		names = append(names, profile)
+	}
+	sort.Strings(names)
+
+	for _, profile := range names {
+		ctx.Ch <- prometheus.MustNewConstMetric(c.profileSessionsDesc, prometheus.GaugeValue, counts[profile], ctx.Device.Name, ctx.Device.Address, profile)
+	}
+
+	return nil
+}
+
+// secretProfiles returns the profile of every secret, refetched once per refresh interval
+func (c *pppCollector) secretProfiles(ctx *collector.Context) (map[string]string, error) {
+	c.secretsMu.Lock()
+	secrets := c.secrets[ctx.Device.Name]
+	c.secretsMu.Unlock()
+
+	if secrets != nil && time.Since(secrets.fetched) < pppSecretsRefreshInterval {
+		return secrets.profiles, nil
+	}
+
+	reply, err := ctx.Client.Run("/ppp/secret/print", "=.proplist=name,profile")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching ppp secrets")
+		return nil, err
+	}
+
+	secrets = &pppSecrets{profiles: make(map[string]string), fetched: time.Now()}
+	for _, re := range reply.Re {
+		secrets.profiles[re.Map["name"]] = re.Map["profile"]
+	}
+
+	c.secretsMu.Lock()
+	c.secrets[ctx.Device.Name] = secrets
+	c.secretsMu.Unlock()
+
+	return secrets.profiles, nil
+}
+
+func (c *pppCollector) collectForUser(user string, ctx *collector.Context) error {
+	reply, err := ctx.Client.Run("/ppp/active/print", fmt.Sprintf("?name=%s", user), "=.proplist=name,service,caller-id,address,uptime")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"user":   user,
+			"error":  err,
+		}).Error("error fetching ppp user session")
+		return err
+	}
+
+	// a user may have several sessions of a service, every session has its own interface
+	services := make(map[string]bool)
+	for _, re := range reply.Re {
+		c.collectUptime(re, ctx)
+		services[re.Map["service"]] = true
+	}
+
+	for _, service := range pppServices {
+		if !services[service] {
+			continue
+		}
+
+		if err := c.collectInterfaces(user, service, ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *pppCollector) collectUptime(re *proto.Sentence, ctx *collector.Context) {
+	value := re.Map["uptime"]
+	if value == "" {
+		return
+	}
+
+	v, err := helper.ParseDuration(value)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device":   ctx.Device.Name,
+			"user":     re.Map["name"],
+			"property": "uptime",
+			"value":    value,
+			"error":    err,
+		}).Error("error parsing ppp session metric value")
+		return
+	}
+
+	ctx.Ch <- prometheus.MustNewConstMetric(c.uptimeDesc, prometheus.GaugeValue, v, ctx.Device.Name, ctx.Device.Address,
+		re.Map["name"], re.Map["service"], re.Map["caller-id"], re.Map["address"])
+}
+
+// collectInterfaces reads the traffic of the dynamic server interfaces of the sessions of a user,
+// named <pppoe-user>, <pppoe-user-1>, ... by RouterOS
+func (c *pppCollector) collectInterfaces(user, service string, ctx *collector.Context) error {
+	reply, err := ctx.Client.Run(fmt.Sprintf("/interface/%s-server/print", service), fmt.Sprintf("?user=%s", user), "=.proplist=name")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device":  ctx.Device.Name,
+			"user":    user,
+			"service": service,
+			"error":   err,
+		}).Error("error fetching ppp session interfaces")
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, re := range reply.Re {
+		iface := re.Map["name"]
+		if iface == "" || seen[iface] {
+			continue
+		}
+		seen[iface] = true
+
+		if err := c.collectInterface(user, service, iface, ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *pppCollector) collectInterface(user, service, iface string, ctx *collector.Context) error {
+	reply, err := ctx.Client.Run("/interface/print", fmt.Sprintf("?name=%s", iface), "=.proplist=rx-byte,tx-byte")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device":    ctx.Device.Name,
+			"interface": iface,
+			"error":     err,
+		}).Error("error fetching ppp session interface")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		for desc, property := range map[*prometheus.Desc]string{c.rxBytesDesc: "rx-byte", c.txBytesDesc: "tx-byte"} {
+			value := re.Map[property]
+			if value == "" {
+				continue
+			}
+
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"device":    ctx.Device.Name,
+					"interface": iface,
+					"property":  property,
+					"value":     value,
+					"error":     err,
+				}).Error("error parsing ppp session interface metric value")
+				continue
+			}
+
+			ctx.Ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v, ctx.Device.Name, ctx.Device.Address, user, service, iface)
+		}
+	}
+
+	return nil
+}
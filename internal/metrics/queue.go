@@ -0,0 +1,163 @@
+package metrics
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/internal/collector"
+	"mikrotik-exporter/internal/helper"
+)
+
+func init() {
+	Registry.Add("queues", newQueueCollector)
+}
+
+type queueMetric struct {
+	property  string
+	name      string
+	help      string
+	valueType prometheus.ValueType
+}
+
+var queueMetrics = []queueMetric{
+	{"bytes", "bytes", "number of bytes passed the queue", prometheus.CounterValue},
+	{"packets", "packets", "number of packets passed the queue", prometheus.CounterValue},
+	{"dropped", "dropped_packets", "number of packets dropped by the queue", prometheus.CounterValue},
+	{"queued-packets", "queued_packets", "number of packets currently queued", prometheus.GaugeValue},
+	{"queued-bytes", "queued_bytes", "number of bytes currently queued", prometheus.GaugeValue},
+	{"rate", "rate_bits_per_second", "current rate of the queue in bits per second", prometheus.GaugeValue},
+	{"max-limit", "max_limit_bits_per_second", "configured max limit of the queue in bits per second (0 = unlimited)", prometheus.GaugeValue},
+}
+
+type queueCollector struct {
+	simpleProps        []string
+	treeProps          []string
+	simpleDescriptions map[string]*prometheus.Desc
+	treeDescriptions   map[string]*prometheus.Desc
+}
+
+func newQueueCollector() collector.Collector {
+	c := &queueCollector{}
+	c.init()
+	return c
+}
+
+func (c *queueCollector) init() {
+	c.simpleProps = []string{"name", "parent", "target", "comment"}
+	c.treeProps = []string{"name", "parent", "comment"}
+
+	simpleLabelNames := []string{"name", "address", "queue", "parent", "target", "comment", "direction"}
+	treeLabelNames := []string{"name", "address", "queue", "parent", "comment"}
+
+	c.simpleDescriptions = make(map[string]*prometheus.Desc)
+	c.treeDescriptions = make(map[string]*prometheus.Desc)
+	for _, m := range queueMetrics {
+		c.simpleProps = append(c.simpleProps, m.property)
+		c.treeProps = append(c.treeProps, m.property)
+		c.simpleDescriptions[m.property] = helper.Description("queue_simple", m.name, m.help, simpleLabelNames)
+		c.treeDescriptions[m.property] = helper.Description("queue_tree", m.name, m.help, treeLabelNames)
+	}
+}
+
+func (c *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range c.simpleDescriptions {
+		ch <- d
+	}
+	for _, d := range c.treeDescriptions {
+		ch <- d
+	}
+}
+
+func (c *queueCollector) Collect(ctx *collector.Context) error {
+	reply, err := ctx.Client.Run("/queue/simple/print", "?disabled=false", "=.proplist="+strings.Join(c.simpleProps, ","))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching simple queue metrics")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		c.collectForSimpleQueue(re, ctx)
+	}
+
+	reply, err = ctx.Client.Run("/queue/tree/print", "?disabled=false", "=.proplist="+strings.Join(c.treeProps, ","))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching queue tree metrics")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		c.collectForQueueTree(re, ctx)
+	}
+
+	return nil
+}
+
+// collectForSimpleQueue splits the upload/download values of simple queues into a direction label
+func (c *queueCollector) collectForSimpleQueue(re *proto.Sentence, ctx *collector.Context) {
+	for _, m := range queueMetrics {
+		value := re.Map[m.property]
+		if value == "" {
+			continue
+		}
+
+		up, down, err := helper.SplitUploadDownload(value)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"device":   ctx.Device.Name,
+				"queue":    re.Map["name"],
+				"property": m.property,
+				"value":    value,
+				"error":    err,
+			}).Error("error parsing simple queue metric value")
+			continue
+		}
+
+		desc := c.simpleDescriptions[m.property]
+		ctx.Ch <- prometheus.MustNewConstMetric(desc, m.valueType, up, ctx.Device.Name, ctx.Device.Address,
+			re.Map["name"], re.Map["parent"], re.Map["target"], re.Map["comment"], "upload")
+		ctx.Ch <- prometheus.MustNewConstMetric(desc, m.valueType, down, ctx.Device.Name, ctx.Device.Address,
+			re.Map["name"], re.Map["parent"], re.Map["target"], re.Map["comment"], "download")
+	}
+}
+
+func (c *queueCollector) collectForQueueTree(re *proto.Sentence, ctx *collector.Context) {
+	for _, m := range queueMetrics {
+		value := re.Map[m.property]
+		if value == "" {
+			continue
+		}
+
+		var (
+			v   float64
+			err error
+		)
+		if m.property == "max-limit" {
+			v, err = helper.ParseRate(value)
+		} else {
+			v, err = strconv.ParseFloat(value, 64)
+		}
+		if err != nil {
+			log.WithFields(log.Fields{
+				"device":   ctx.Device.Name,
+				"queue":    re.Map["name"],
+				"property": m.property,
+				"value":    value,
+				"error":    err,
+			}).Error("error parsing queue tree metric value")
+			continue
+		}
+
+		ctx.Ch <- prometheus.MustNewConstMetric(c.treeDescriptions[m.property], m.valueType, v, ctx.Device.Name, ctx.Device.Address,
+			re.Map["name"], re.Map["parent"], re.Map["comment"])
+	}
+}
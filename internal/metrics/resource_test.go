@@ -2,6 +2,8 @@ package metrics
 
 import (
 	"testing"
+
+	"mikrotik-exporter/internal/helper"
 )
 
 func TestParseUptime(t *testing.T) {
@@ -17,7 +19,7 @@ func TestParseUptime(t *testing.T) {
 	}
 
 	for _, uptime := range uptimes {
-		seconds, err := parseUptime(uptime.u)
+		seconds, err := helper.ParseDuration(uptime.u)
 		if err != nil {
 			t.Error(err)
 		}
@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/internal/collector"
+	"mikrotik-exporter/internal/helper"
+)
+
+func init() {
+	Registry.Add("vrrp", newVRRPCollector)
+}
+
+// vrrpStates maps instance states to the values of vrrpOperState in the VRRP MIB
+var vrrpStates = map[string]float64{
+	"init":   1,
+	"backup": 2,
+	"master": 3,
+}
+
+type vrrpCollector struct {
+	stateDesc    *prometheus.Desc
+	masterDesc   *prometheus.Desc
+	priorityDesc *prometheus.Desc
+	addressDesc  *prometheus.Desc
+}
+
+func newVRRPCollector() collector.Collector {
+	c := &vrrpCollector{}
+	c.init()
+	return c
+}
+
+func (c *vrrpCollector) init() {
+	const prefix = "vrrp"
+
+	labelNames := []string{"name", "address", "instance", "interface", "vrid"}
+	c.stateDesc = helper.Description(prefix, "state", "state of the VRRP instance (1 = init, 2 = backup, 3 = master)", labelNames)
+	c.masterDesc = helper.Description(prefix, "master", "VRRP instance is master (master = 1)", labelNames)
+	c.priorityDesc = helper.Description(prefix, "priority", "configured priority of the VRRP instance", labelNames)
+	c.addressDesc = helper.Description(prefix, "address_info", "virtual address of the VRRP instance", []string{"name", "address", "instance", "virtual_address"})
+}
+
+func (c *vrrpCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.stateDesc
+	ch <- c.masterDesc
+	ch <- c.priorityDesc
+	ch <- c.addressDesc
+}
+
+func (c *vrrpCollector) Collect(ctx *collector.Context) error {
+	reply, err := ctx.Client.Run("/interface/vrrp/print", "?disabled=false", "=.proplist=name,interface,vrid,priority,master,backup")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching vrrp instances")
+		return err
+	}
+	if len(reply.Re) == 0 {
+		return nil
+	}
+
+	addresses := make(map[string][]string)
+	for _, topic := range []string{"ip", "ipv6"} {
+		if err := c.fetchAddresses(topic, addresses, ctx); err != nil {
+			return err
+		}
+	}
+
+	for _, re := range reply.Re {
+		c.collectForInstance(re, ctx)
+		c.collectAddresses(re.Map["name"], addresses[re.Map["name"]], ctx)
+	}
+
+	return nil
+}
+
+// fetchAddresses adds the addresses of every interface to addresses, the virtual addresses
+// of a VRRP instance are the addresses assigned to its interface
+func (c *vrrpCollector) fetchAddresses(topic string, addresses map[string][]string, ctx *collector.Context) error {
+	reply, err := ctx.Client.Run(fmt.Sprintf("/%s/address/print", topic), "?disabled=false", "=.proplist=address,interface")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"topic":  topic,
+			"error":  err,
+		}).Error("error fetching vrrp addresses")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		addresses[re.Map["interface"]] = append(addresses[re.Map["interface"]], re.Map["address"])
+	}
+
+	return nil
+}
+
+func (c *vrrpCollector) collectAddresses(instance string, addresses []string, ctx *collector.Context) {
+	sort.Strings(addresses)
+
+	for i, address := range addresses {
+		// an address may be assigned twice, e.g. with different networks
+		if i > 0 && addresses[i-1] == address {
+			continue
+		}
+
+		ctx.Ch <- prometheus.MustNewConstMetric(c.addressDesc, prometheus.GaugeValue, 1, ctx.Device.Name, ctx.Device.Address, instance, address)
+	}
+}
+
+func (c *vrrpCollector) collectForInstance(re *proto.Sentence, ctx *collector.Context) {
+	state := "init"
+	switch {
+	case re.Map["master"] == "true":
+		state = "master"
+	case re.Map["backup"] == "true":
+		state = "backup"
+	}
+
+	labelValues := []string{ctx.Device.Name, ctx.Device.Address, re.Map["name"], re.Map["interface"], re.Map["vrid"]}
+
+	ctx.Ch <- prometheus.MustNewConstMetric(c.stateDesc, prometheus.GaugeValue, vrrpStates[state], labelValues...)
+
+	master := 0.0
+	if state == "master" {
+		master = 1
+	}
+	ctx.Ch <- prometheus.MustNewConstMetric(c.masterDesc, prometheus.GaugeValue, master, labelValues...)
+
+	if value := re.Map["priority"]; value != "" {
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"device":   ctx.Device.Name,
+				"instance": re.Map["name"],
+				"property": "priority",
+				"value":    value,
+				"error":    err,
+			}).Error("error parsing vrrp metric value")
+			return
+		}
+
+		ctx.Ch <- prometheus.MustNewConstMetric(c.priorityDesc, prometheus.GaugeValue, v, labelValues...)
+	}
+}
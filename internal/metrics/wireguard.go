@@ -0,0 +1,162 @@
+package metrics
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/internal/collector"
+	"mikrotik-exporter/internal/config"
+	"mikrotik-exporter/internal/helper"
+)
+
+func init() {
+	Registry.Add("wireguard", newWireGuardCollector)
+}
+
+// wireGuardDefaultHandshakeThreshold allows for the rekey interval of 2 minutes plus retries
+const wireGuardDefaultHandshakeThreshold = 3 * time.Minute
+
+type wireGuardCollector struct {
+	peerProps          []string
+	handshakeThreshold time.Duration
+	interfaceDesc      *prometheus.Desc
+	rxBytesDesc        *prometheus.Desc
+	txBytesDesc        *prometheus.Desc
+	lastHandshakeDesc  *prometheus.Desc
+	upDesc             *prometheus.Desc
+}
+
+func newWireGuardCollector() collector.Collector {
+	c := &wireGuardCollector{}
+	c.init()
+	return c
+}
+
+func (c *wireGuardCollector) init() {
+	c.peerProps = []string{"interface", "public-key", "comment", "allowed-address", "current-endpoint-address",
+		"current-endpoint-port", "rx", "tx", "last-handshake"}
+	c.handshakeThreshold = wireGuardDefaultHandshakeThreshold
+
+	const prefix = "wireguard_peer"
+	labelNames := []string{"name", "address", "interface", "public_key", "comment", "endpoint", "allowed_address"}
+
+	c.interfaceDesc = helper.Description("wireguard_interface", "running", "WireGuard interface is running (running = 1)",
+		[]string{"name", "address", "interface", "listen_port", "public_key"})
+	c.rxBytesDesc = helper.Description(prefix, "rx_bytes", "number of bytes received from the peer", labelNames)
+	c.txBytesDesc = helper.Description(prefix, "tx_bytes", "number of bytes sent to the peer", labelNames)
+	c.lastHandshakeDesc = helper.Description(prefix, "last_handshake_seconds", "time since the last handshake with the peer in seconds", labelNames)
+	c.upDesc = helper.Description(prefix, "up", "last handshake with the peer is within the configured threshold (up = 1)", labelNames)
+}
+
+func (c *wireGuardCollector) configure(cfg *config.Collectors) error {
+	if cfg.WireGuard.HandshakeThreshold > 0 {
+		c.handshakeThreshold = cfg.WireGuard.HandshakeThreshold
+	}
+
+	return nil
+}
+
+func (c *wireGuardCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.interfaceDesc
+	ch <- c.rxBytesDesc
+	ch <- c.txBytesDesc
+	ch <- c.lastHandshakeDesc
+	ch <- c.upDesc
+}
+
+func (c *wireGuardCollector) Collect(ctx *collector.Context) error {
+	reply, err := ctx.Client.Run("/interface/wireguard/print", "?disabled=false", "=.proplist=name,listen-port,public-key,running")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching wireguard interfaces")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		v := 0.0
+		if re.Map["running"] == "true" {
+			v = 1
+		}
+
+		ctx.Ch <- prometheus.MustNewConstMetric(c.interfaceDesc, prometheus.GaugeValue, v, ctx.Device.Name, ctx.Device.Address,
+			re.Map["name"], re.Map["listen-port"], re.Map["public-key"])
+	}
+
+	reply, err = ctx.Client.Run("/interface/wireguard/peers/print", "?disabled=false", "=.proplist="+strings.Join(c.peerProps, ","))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": ctx.Device.Name,
+			"error":  err,
+		}).Error("error fetching wireguard peers")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		c.collectForPeer(re, ctx)
+	}
+
+	return nil
+}
+
+func (c *wireGuardCollector) collectForPeer(re *proto.Sentence, ctx *collector.Context) {
+	var endpoint string
+	if re.Map["current-endpoint-address"] != "" {
+		endpoint = net.JoinHostPort(re.Map["current-endpoint-address"], re.Map["current-endpoint-port"])
+	}
+
+	labelValues := []string{ctx.Device.Name, ctx.Device.Address, re.Map["interface"], re.Map["public-key"],
+		re.Map["comment"], endpoint, re.Map["allowed-address"]}
+
+	c.collectBytes(c.rxBytesDesc, "rx", labelValues, re, ctx)
+	c.collectBytes(c.txBytesDesc, "tx", labelValues, re, ctx)
+
+	up := 0.0
+	// peers without any handshake do not report last-handshake
+	if value := re.Map["last-handshake"]; value != "" {
+		v, err := helper.ParseDuration(value)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"device":   ctx.Device.Name,
+				"peer":     re.Map["public-key"],
+				"property": "last-handshake",
+				"value":    value,
+				"error":    err,
+			}).Error("error parsing wireguard peer metric value")
+		} else {
+			ctx.Ch <- prometheus.MustNewConstMetric(c.lastHandshakeDesc, prometheus.GaugeValue, v, labelValues...)
+			if v <= c.handshakeThreshold.Seconds() {
+				up = 1
+			}
+		}
+	}
+	ctx.Ch <- prometheus.MustNewConstMetric(c.upDesc, prometheus.GaugeValue, up, labelValues...)
+}
+
+func (c *wireGuardCollector) collectBytes(desc *prometheus.Desc, property string, labelValues []string, re *proto.Sentence, ctx *collector.Context) {
+	value := re.Map[property]
+	if value == "" {
+		return
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device":   ctx.Device.Name,
+			"peer":     re.Map["public-key"],
+			"property": property,
+			"value":    value,
+			"error":    err,
+		}).Error("error parsing wireguard peer metric value")
+		return
+	}
+
+	ctx.Ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v, labelValues...)
+}
@@ -0,0 +1,216 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"mikrotik-exporter/internal/config"
+	"mikrotik-exporter/internal/helper"
+)
+
+func init() {
+	Registry.Add("influxdb", newInfluxDBSink)
+}
+
+const (
+	influxDBDefaultBatchSize    = 5000
+	influxDBDefaultRetries      = 3
+	influxDBDefaultRetryBackoff = time.Second
+)
+
+var (
+	influxDBMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	influxDBKeyEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+type influxDBSink struct {
+	client       *http.Client
+	endpoint     string
+	token        string
+	batchSize    int
+	retries      int
+	retryBackoff time.Duration
+	timeout      time.Duration
+}
+
+func newInfluxDBSink(cfg *config.Outputs) (Sink, error) {
+	c := cfg.InfluxDB
+	if c == nil {
+		return nil, nil
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("url %q is not valid", c.URL)
+	}
+	if c.Bucket == "" {
+		return nil, fmt.Errorf("bucket is required")
+	}
+
+	u.Path = strings.TrimRight(u.Path, "/") + "/api/v2/write"
+	q := u.Query()
+	q.Set("org", c.Org)
+	q.Set("bucket", c.Bucket)
+	q.Set("precision", "ns")
+	u.RawQuery = q.Encode()
+
+	s := &influxDBSink{
+		client: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: c.Insecure},
+			},
+		},
+		endpoint:     u.String(),
+		token:        c.Token,
+		batchSize:    c.BatchSize,
+		retries:      c.Retries,
+		retryBackoff: c.RetryBackoff,
+		timeout:      c.Timeout,
+	}
+	if s.batchSize <= 0 {
+		s.batchSize = influxDBDefaultBatchSize
+	}
+	if s.retries < 0 {
+		s.retries = 0
+	} else if s.retries == 0 {
+		s.retries = influxDBDefaultRetries
+	}
+	if s.retryBackoff <= 0 {
+		s.retryBackoff = influxDBDefaultRetryBackoff
+	}
+
+	return s, nil
+}
+
+func (s *influxDBSink) Name() string {
+	return "influxdb"
+}
+
+func (s *influxDBSink) Write(ctx context.Context, snap *Snapshot) error {
+	lines := influxDBLines(snap)
+
+	for start := 0; start < len(lines); start += s.batchSize {
+		end := start + s.batchSize
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		body := []byte(strings.Join(lines[start:end], "\n") + "\n")
+		err := retry(ctx, s.Name(), s.retries, s.retryBackoff, func() error {
+			return s.post(ctx, body)
+		})
+		if err != nil {
+			return fmt.Errorf("could not write lines %d to %d of %d: %w", start, end, len(lines), err)
+		}
+	}
+
+	return nil
+}
+
+func (s *influxDBSink) post(ctx context.Context, body []byte) error {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if s.token != "" {
+		r.Header.Set("Authorization", "Token "+s.token)
+	}
+
+	res, err := s.client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return checkResponse(res)
+}
+
+// influxDBLines converts the snapshot into line protocol, using the collector prefix of a metric
+// as measurement and the rest of its name as field. Series sharing measurement and tags are
+// merged into a single line.
+func influxDBLines(snap *Snapshot) []string {
+	var (
+		keys   []string
+		fields = make(map[string][]string)
+		ts     = strconv.FormatInt(snap.Time.UnixNano(), 10)
+	)
+
+	for _, mf := range snap.Families {
+		measurement, field := helper.SplitName(mf.GetName())
+
+		for _, m := range mf.GetMetric() {
+			v, ok := metricValue(mf.GetType(), m)
+			if !ok {
+				continue
+			}
+
+			key := influxDBMeasurementEscaper.Replace(measurement) + influxDBTags(m.GetLabel())
+			if _, exists := fields[key]; !exists {
+				keys = append(keys, key)
+			}
+			fields[key] = append(fields[key], influxDBKeyEscaper.Replace(field)+"="+strconv.FormatFloat(v, 'g', -1, 64))
+		}
+	}
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, key+" "+strings.Join(fields[key], ",")+" "+ts)
+	}
+
+	return lines
+}
+
+// influxDBTags renders the labels as sorted tag set, the device labels are written as device and address
+func influxDBTags(labels []*dto.LabelPair) string {
+	device, address, rest := splitDeviceLabels(labels)
+
+	tags := make(map[string]string, len(rest)+2)
+	for _, l := range rest {
+		tags[l.GetName()] = l.GetValue()
+	}
+	if device != "" {
+		tags["device"] = device
+	}
+	if address != "" {
+		tags["address"] = address
+	}
+
+	names := make([]string, 0, len(tags))
+	for n, v := range tags {
+		// empty tag values are not allowed in line protocol
+		if v != "" {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, n := range names {
+		b.WriteString(",")
+		b.WriteString(influxDBKeyEscaper.Replace(n))
+		b.WriteString("=")
+		b.WriteString(influxDBKeyEscaper.Replace(tags[n]))
+	}
+
+	return b.String()
+}
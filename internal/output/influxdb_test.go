@@ -0,0 +1,133 @@
+package output
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+
+	"mikrotik-exporter/internal/config"
+	"mikrotik-exporter/internal/helper"
+)
+
+func init() {
+	helper.Description("interface", "rx_byte", "", nil)
+	helper.Description("scrape", "collector_success", "", nil)
+}
+
+func TestInfluxDBLines(t *testing.T) {
+	gauge := dto.MetricType_GAUGE
+	snap := testSnapshot()
+	snap.Families = append(snap.Families, &dto.MetricFamily{
+		Name: strPtr("mikrotik_interface_tx_byte"),
+		Type: &gauge,
+		Metric: []*dto.Metric{
+			{
+				Label: labelPairs("name", "router1", "address", "10.0.0.1", "interface", "ether1"),
+				Gauge: &dto.Gauge{Value: floatPtr(99)},
+			},
+			{
+				Label: labelPairs("name", "router1", "address", "10.0.0.1", "interface", "my bridge,1", "comment", ""),
+				Gauge: &dto.Gauge{Value: floatPtr(1.5)},
+			},
+		},
+	})
+
+	assert.Equal(t, []string{
+		"interface,address=10.0.0.1,device=router1,interface=ether1 rx_byte=1234,tx_byte=99 1600000000000000000",
+		"interface,address=10.0.0.2,device=router2,interface=ether1 rx_byte=42 1600000000000000000",
+		"scrape,device=router1 collector_success=1 1600000000000000000",
+		`interface,address=10.0.0.1,device=router1,interface=my\ bridge\,1 tx_byte=1.5 1600000000000000000`,
+	}, influxDBLines(snap))
+}
+
+func TestInfluxDBWrite(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v2/write", r.URL.Path)
+		assert.Equal(t, "myorg", r.URL.Query().Get("org"))
+		assert.Equal(t, "routers", r.URL.Query().Get("bucket"))
+		assert.Equal(t, "ns", r.URL.Query().Get("precision"))
+		assert.Equal(t, "Token secret", r.Header.Get("Authorization"))
+
+		b, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(b))
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	s, err := newInfluxDBSink(&config.Outputs{InfluxDB: &config.InfluxDB{
+		URL:       srv.URL,
+		Org:       "myorg",
+		Bucket:    "routers",
+		Token:     "secret",
+		BatchSize: 2,
+	}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, s.Write(context.Background(), testSnapshot()))
+	if assert.Len(t, bodies, 2) {
+		assert.Equal(t, 2, strings.Count(bodies[0], "\n"))
+		assert.Equal(t, 1, strings.Count(bodies[1], "\n"))
+	}
+}
+
+func TestInfluxDBRetry(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	s, err := newInfluxDBSink(&config.Outputs{InfluxDB: &config.InfluxDB{
+		URL:          srv.URL,
+		Bucket:       "routers",
+		RetryBackoff: time.Millisecond,
+	}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, s.Write(context.Background(), testSnapshot()))
+	assert.Equal(t, 3, attempts)
+}
+
+func TestInfluxDBNoRetryOnClientError(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		http.Error(w, "bad line", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	s, err := newInfluxDBSink(&config.Outputs{InfluxDB: &config.InfluxDB{
+		URL:          srv.URL,
+		Bucket:       "routers",
+		RetryBackoff: time.Millisecond,
+	}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Error(t, s.Write(context.Background(), testSnapshot()))
+	assert.Equal(t, 1, attempts)
+}
@@ -0,0 +1,46 @@
+package output
+
+import (
+	dto "github.com/prometheus/client_model/go"
+)
+
+// deviceLabelNames are the label names collectors use for the device name, in order of precedence.
+// Collectors which use "name" for something else (e.g. package names) carry a "devicename" label.
+var deviceLabelNames = []string{"devicename", "device", "name"}
+
+const addressLabelName = "address"
+
+// splitDeviceLabels separates the labels identifying the device from the remaining labels of a metric
+func splitDeviceLabels(labels []*dto.LabelPair) (device, address string, rest []*dto.LabelPair) {
+	deviceLabel := ""
+	for _, n := range deviceLabelNames {
+		if hasLabel(labels, n) {
+			deviceLabel = n
+			break
+		}
+	}
+
+	rest = make([]*dto.LabelPair, 0, len(labels))
+	for _, l := range labels {
+		switch l.GetName() {
+		case deviceLabel:
+			device = l.GetValue()
+		case addressLabelName:
+			address = l.GetValue()
+		default:
+			rest = append(rest, l)
+		}
+	}
+
+	return device, address, rest
+}
+
+func hasLabel(labels []*dto.LabelPair, name string) bool {
+	for _, l := range labels {
+		if l.GetName() == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,317 @@
+package output
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	log "github.com/sirupsen/logrus"
+
+	"mikrotik-exporter/internal/config"
+	"mikrotik-exporter/internal/helper"
+)
+
+func init() {
+	Registry.Add("mqtt", newMQTTSink)
+}
+
+const (
+	mqttDefaultTopicPrefix = "mikrotik"
+	mqttDefaultTimeout     = 10 * time.Second
+	mqttStatusTopic        = "status"
+	mqttStatusOnline       = "online"
+	mqttStatusOffline      = "offline"
+
+	// scrapeSuccessMetric reports per device whether the last poll succeeded
+	scrapeSuccessMetric = "mikrotik_scrape_collector_success"
+)
+
+var mqttTopicEscaper = strings.NewReplacer("/", "_", "+", "_", "#", "_")
+
+type mqttPublisher interface {
+	publish(ctx context.Context, topic string, qos byte, retained bool, payload []byte) error
+}
+
+type mqttSink struct {
+	publisher mqttPublisher
+	prefix    string
+	qos       byte
+	retain    bool
+}
+
+type mqttMetric struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  float64           `json:"value"`
+}
+
+type mqttPayload struct {
+	Timestamp time.Time    `json:"timestamp"`
+	Device    string       `json:"device"`
+	Address   string       `json:"address,omitempty"`
+	Feature   string       `json:"feature"`
+	Metrics   []mqttMetric `json:"metrics"`
+}
+
+type mqttMessage struct {
+	topic    string
+	retained bool
+	payload  []byte
+}
+
+func newMQTTSink(cfg *config.Outputs) (Sink, error) {
+	c := cfg.MQTT
+	if c == nil {
+		return nil, nil
+	}
+
+	if c.Broker == "" {
+		return nil, fmt.Errorf("broker is required")
+	}
+	if c.QoS > 2 {
+		return nil, fmt.Errorf("qos %d is not valid", c.QoS)
+	}
+
+	p, err := newPahoPublisher(c)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := strings.TrimRight(c.TopicPrefix, "/")
+	if prefix == "" {
+		prefix = mqttDefaultTopicPrefix
+	}
+
+	return &mqttSink{
+		publisher: p,
+		prefix:    prefix,
+		qos:       c.QoS,
+		retain:    c.Retain,
+	}, nil
+}
+
+func (s *mqttSink) Name() string {
+	return "mqtt"
+}
+
+func (s *mqttSink) Write(ctx context.Context, snap *Snapshot) error {
+	msgs, err := s.messages(snap)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, m := range msgs {
+		if err = s.publisher.publish(ctx, m.topic, s.qos, m.retained, m.payload); err != nil {
+			failed++
+			log.WithFields(log.Fields{
+				"topic": m.topic,
+				"error": err,
+			}).Debug("error publishing mqtt message")
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("could not publish %d of %d messages: %w", failed, len(msgs), err)
+	}
+
+	return nil
+}
+
+// messages builds one message per device and feature on <prefix>/<device>/<feature> and a
+// retained online/offline message per device on <prefix>/<device>/status
+func (s *mqttSink) messages(snap *Snapshot) ([]mqttMessage, error) {
+	type topicKey struct{ device, feature string }
+
+	var (
+		keys     []topicKey
+		payloads = make(map[topicKey]*mqttPayload)
+		devices  []string
+		online   = make(map[string]bool)
+	)
+
+	for _, mf := range snap.Families {
+		feature, name := helper.SplitName(mf.GetName())
+
+		for _, m := range mf.GetMetric() {
+			v, ok := metricValue(mf.GetType(), m)
+			if !ok {
+				continue
+			}
+
+			device, address, rest := splitDeviceLabels(m.GetLabel())
+			if device == "" {
+				continue
+			}
+
+			if mf.GetName() == scrapeSuccessMetric {
+				if _, exists := online[device]; !exists {
+					devices = append(devices, device)
+				}
+				online[device] = v == 1
+			}
+
+			key := topicKey{device, feature}
+			p, exists := payloads[key]
+			if !exists {
+				p = &mqttPayload{
+					Timestamp: snap.Time,
+					Device:    device,
+					Feature:   feature,
+				}
+				payloads[key] = p
+				keys = append(keys, key)
+			}
+			if address != "" {
+				p.Address = address
+			}
+
+			metric := mqttMetric{Name: name, Value: v}
+			if len(rest) > 0 {
+				metric.Labels = make(map[string]string, len(rest))
+				for _, l := range rest {
+					metric.Labels[l.GetName()] = l.GetValue()
+				}
+			}
+			p.Metrics = append(p.Metrics, metric)
+		}
+	}
+
+	msgs := make([]mqttMessage, 0, len(keys)+len(devices))
+	for _, key := range keys {
+		b, err := json.Marshal(payloads[key])
+		if err != nil {
+			return nil, err
+		}
+
+		msgs = append(msgs, mqttMessage{
+			topic:    s.topic(key.device, key.feature),
+			retained: s.retain,
+			payload:  b,
+		})
+	}
+
+	for _, device := range devices {
+		status := mqttStatusOffline
+		if online[device] {
+			status = mqttStatusOnline
+		}
+
+		msgs = append(msgs, mqttMessage{
+			topic:    s.topic(device, mqttStatusTopic),
+			retained: true,
+			payload:  []byte(status),
+		})
+	}
+
+	return msgs, nil
+}
+
+func (s *mqttSink) topic(device, sub string) string {
+	return s.prefix + "/" + mqttTopicEscaper.Replace(device) + "/" + sub
+}
+
+type pahoPublisher struct {
+	client  mqtt.Client
+	timeout time.Duration
+}
+
+func newPahoPublisher(c *config.MQTT) (mqttPublisher, error) {
+	opts := mqtt.NewClientOptions().
+		AddBroker(c.Broker).
+		SetUsername(c.Username).
+		SetPassword(c.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true)
+
+	clientID := c.ClientID
+	if clientID == "" {
+		hostname, _ := os.Hostname()
+		clientID = "mikrotik-exporter-" + hostname
+	}
+	opts.SetClientID(clientID)
+
+	if c.TLS != nil {
+		tlsCfg, err := newTLSConfig(c.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetTLSConfig(tlsCfg)
+	}
+
+	opts.SetOnConnectHandler(func(mqtt.Client) {
+		log.WithField("broker", c.Broker).Info("connected to mqtt broker")
+	})
+	opts.SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+		log.WithFields(log.Fields{
+			"broker": c.Broker,
+			"error":  err,
+		}).Warn("lost connection to mqtt broker")
+	})
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = mqttDefaultTimeout
+	}
+
+	client := mqtt.NewClient(opts)
+	// with connect retry enabled the client keeps trying in the background
+	client.Connect()
+
+	return &pahoPublisher{
+		client:  client,
+		timeout: timeout,
+	}, nil
+}
+
+func (p *pahoPublisher) publish(ctx context.Context, topic string, qos byte, retained bool, payload []byte) error {
+	if !p.client.IsConnectionOpen() {
+		return errors.New("not connected to mqtt broker")
+	}
+
+	t := p.client.Publish(topic, qos, retained, payload)
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	select {
+	case <-t.Done():
+		return t.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func newTLSConfig(c *config.TLS) (*tls.Config, error) {
+	tlsCfg := &tls.Config{InsecureSkipVerify: c.Insecure}
+
+	if c.CAFile != "" {
+		ca, err := ioutil.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsCfg.RootCAs = x509.NewCertPool()
+		if !tlsCfg.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in %s", c.CAFile)
+		}
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsCfg, nil
+}
@@ -0,0 +1,78 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+type publishedMessage struct {
+	topic    string
+	qos      byte
+	retained bool
+	payload  string
+}
+
+type fakePublisher struct {
+	messages []publishedMessage
+	err      error
+}
+
+func (p *fakePublisher) publish(_ context.Context, topic string, qos byte, retained bool, payload []byte) error {
+	if p.err != nil {
+		return p.err
+	}
+
+	p.messages = append(p.messages, publishedMessage{topic, qos, retained, string(payload)})
+	return nil
+}
+
+func TestMQTTWrite(t *testing.T) {
+	gauge := dto.MetricType_GAUGE
+	snap := testSnapshot()
+	snap.Families = append(snap.Families, &dto.MetricFamily{
+		Name: strPtr("mikrotik_scrape_collector_success"),
+		Type: &gauge,
+		Metric: []*dto.Metric{
+			{
+				Label: labelPairs("device", "router/2"),
+				Gauge: &dto.Gauge{Value: floatPtr(0)},
+			},
+		},
+	})
+
+	p := &fakePublisher{}
+	s := &mqttSink{publisher: p, prefix: "noc", qos: 1}
+
+	assert.NoError(t, s.Write(context.Background(), snap))
+	if !assert.Len(t, p.messages, 6) {
+		return
+	}
+
+	assert.Equal(t, "noc/router1/interface", p.messages[0].topic)
+	assert.Equal(t, byte(1), p.messages[0].qos)
+	assert.False(t, p.messages[0].retained)
+
+	payload := mqttPayload{}
+	assert.NoError(t, json.Unmarshal([]byte(p.messages[0].payload), &payload))
+	assert.Equal(t, "router1", payload.Device)
+	assert.Equal(t, "10.0.0.1", payload.Address)
+	assert.Equal(t, "interface", payload.Feature)
+	assert.Equal(t, []mqttMetric{{Name: "rx_byte", Labels: map[string]string{"interface": "ether1"}, Value: 1234}}, payload.Metrics)
+
+	assert.Equal(t, "noc/router2/interface", p.messages[1].topic)
+	assert.Equal(t, "noc/router1/scrape", p.messages[2].topic)
+	assert.Equal(t, "noc/router_2/scrape", p.messages[3].topic)
+
+	assert.Equal(t, publishedMessage{"noc/router1/status", 1, true, "online"}, p.messages[4])
+	assert.Equal(t, publishedMessage{"noc/router_2/status", 1, true, "offline"}, p.messages[5])
+}
+
+func TestMQTTWriteError(t *testing.T) {
+	s := &mqttSink{publisher: &fakePublisher{err: errors.New("not connected")}, prefix: "mikrotik"}
+	assert.Error(t, s.Write(context.Background(), testSnapshot()))
+}
@@ -0,0 +1,332 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+
+	"mikrotik-exporter/internal/config"
+)
+
+func init() {
+	Registry.Add("otlp", newOTLPSink)
+}
+
+const (
+	otlpScopeName   = "mikrotik-exporter"
+	otlpServiceName = "mikrotik-exporter"
+	otlpHTTPPath    = "/v1/metrics"
+)
+
+type otlpExporter interface {
+	export(ctx context.Context, req *colmetricpb.ExportMetricsServiceRequest) error
+}
+
+type otlpSink struct {
+	exporter otlpExporter
+	timeout  time.Duration
+	start    time.Time
+}
+
+func newOTLPSink(cfg *config.Outputs) (Sink, error) {
+	if cfg.OTLP == nil {
+		return nil, nil
+	}
+
+	var (
+		e   otlpExporter
+		err error
+	)
+	switch strings.ToLower(cfg.OTLP.Protocol) {
+	case "", "grpc":
+		e, err = newOTLPGRPCExporter(cfg.OTLP)
+	case "http", "http/protobuf":
+		e, err = newOTLPHTTPExporter(cfg.OTLP)
+	default:
+		err = fmt.Errorf("unsupported protocol %q", cfg.OTLP.Protocol)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &otlpSink{
+		exporter: e,
+		timeout:  cfg.OTLP.Timeout,
+		start:    time.Now(),
+	}, nil
+}
+
+func (s *otlpSink) Name() string {
+	return "otlp"
+}
+
+func (s *otlpSink) Write(ctx context.Context, snap *Snapshot) error {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	return s.exporter.export(ctx, s.request(snap))
+}
+
+// request converts the snapshot into an export request with one resource per device
+func (s *otlpSink) request(snap *Snapshot) *colmetricpb.ExportMetricsServiceRequest {
+	var (
+		devices   []string
+		addresses = make(map[string]string)
+		scopes    = make(map[string]*metricpb.ScopeMetrics)
+		metrics   = make(map[string]map[string]*metricpb.Metric)
+		start     = uint64(s.start.UnixNano())
+		ts        = uint64(snap.Time.UnixNano())
+	)
+
+	for _, mf := range snap.Families {
+		for _, m := range mf.GetMetric() {
+			device, address, rest := splitDeviceLabels(m.GetLabel())
+			if address != "" {
+				addresses[device] = address
+			}
+
+			sm, exists := scopes[device]
+			if !exists {
+				sm = &metricpb.ScopeMetrics{
+					Scope: &commonpb.InstrumentationScope{Name: otlpScopeName},
+				}
+				scopes[device] = sm
+				metrics[device] = make(map[string]*metricpb.Metric)
+				devices = append(devices, device)
+			}
+
+			om, exists := metrics[device][mf.GetName()]
+			if !exists {
+				om = newOTLPMetric(mf)
+				if om == nil {
+					continue
+				}
+				metrics[device][mf.GetName()] = om
+				sm.Metrics = append(sm.Metrics, om)
+			}
+
+			addOTLPDataPoint(om, m, otlpAttributes(rest), start, ts)
+		}
+	}
+
+	req := &colmetricpb.ExportMetricsServiceRequest{}
+	for _, device := range devices {
+		attrs := []*commonpb.KeyValue{otlpAttribute("service.name", otlpServiceName)}
+		if device != "" {
+			attrs = append(attrs, otlpAttribute("device", device))
+		}
+		if address := addresses[device]; address != "" {
+			attrs = append(attrs, otlpAttribute("address", address))
+		}
+
+		req.ResourceMetrics = append(req.ResourceMetrics, &metricpb.ResourceMetrics{
+			Resource:     &resourcepb.Resource{Attributes: attrs},
+			ScopeMetrics: []*metricpb.ScopeMetrics{scopes[device]},
+		})
+	}
+
+	return req
+}
+
+// newOTLPMetric maps counters to cumulative monotonic sums and gauges and untyped metrics to gauges
+func newOTLPMetric(mf *dto.MetricFamily) *metricpb.Metric {
+	m := &metricpb.Metric{
+		Name:        mf.GetName(),
+		Description: mf.GetHelp(),
+	}
+
+	switch mf.GetType() {
+	case dto.MetricType_COUNTER:
+		m.Data = &metricpb.Metric_Sum{Sum: &metricpb.Sum{
+			AggregationTemporality: metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+			IsMonotonic:            true,
+		}}
+	case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+		m.Data = &metricpb.Metric_Gauge{Gauge: &metricpb.Gauge{}}
+	default:
+		return nil
+	}
+
+	return m
+}
+
+func addOTLPDataPoint(om *metricpb.Metric, m *dto.Metric, attrs []*commonpb.KeyValue, start, ts uint64) {
+	switch d := om.Data.(type) {
+	case *metricpb.Metric_Sum:
+		d.Sum.DataPoints = append(d.Sum.DataPoints, &metricpb.NumberDataPoint{
+			Attributes:        attrs,
+			StartTimeUnixNano: start,
+			TimeUnixNano:      ts,
+			Value:             &metricpb.NumberDataPoint_AsDouble{AsDouble: m.GetCounter().GetValue()},
+		})
+	case *metricpb.Metric_Gauge:
+		v := m.GetGauge().GetValue()
+		if m.Untyped != nil {
+			v = m.GetUntyped().GetValue()
+		}
+		d.Gauge.DataPoints = append(d.Gauge.DataPoints, &metricpb.NumberDataPoint{
+			Attributes:   attrs,
+			TimeUnixNano: ts,
+			Value:        &metricpb.NumberDataPoint_AsDouble{AsDouble: v},
+		})
+	}
+}
+
+func otlpAttributes(labels []*dto.LabelPair) []*commonpb.KeyValue {
+	attrs := make([]*commonpb.KeyValue, 0, len(labels))
+	for _, l := range labels {
+		attrs = append(attrs, otlpAttribute(l.GetName(), l.GetValue()))
+	}
+
+	return attrs
+}
+
+func otlpAttribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{
+		Key:   key,
+		Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}},
+	}
+}
+
+type otlpGRPCExporter struct {
+	client  colmetricpb.MetricsServiceClient
+	headers metadata.MD
+}
+
+func newOTLPGRPCExporter(cfg *config.OTLP) (otlpExporter, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "localhost:4317"
+	}
+
+	creds := credentials.NewTLS(&tls.Config{})
+	if cfg.Insecure {
+		creds = insecure.NewCredentials()
+	}
+
+	conn, err := grpc.NewClient(endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+
+	return &otlpGRPCExporter{
+		client:  colmetricpb.NewMetricsServiceClient(conn),
+		headers: metadata.New(cfg.Headers),
+	}, nil
+}
+
+func (e *otlpGRPCExporter) export(ctx context.Context, req *colmetricpb.ExportMetricsServiceRequest) error {
+	if len(e.headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, e.headers)
+	}
+
+	resp, err := e.client.Export(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return otlpPartialSuccessError(resp)
+}
+
+type otlpHTTPExporter struct {
+	client   *http.Client
+	endpoint string
+	headers  map[string]string
+}
+
+func newOTLPHTTPExporter(cfg *config.OTLP) (otlpExporter, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "http://localhost:4318"
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("endpoint %q must be an URL for the http protocol", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = otlpHTTPPath
+	}
+
+	return &otlpHTTPExporter{
+		client: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: cfg.Insecure},
+			},
+		},
+		endpoint: u.String(),
+		headers:  cfg.Headers,
+	}, nil
+}
+
+func (e *otlpHTTPExporter) export(ctx context.Context, req *colmetricpb.ExportMetricsServiceRequest) error {
+	body, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/x-protobuf")
+	for k, v := range e.headers {
+		r.Header.Set(k, v)
+	}
+
+	res, err := e.client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	b, err := ioutil.ReadAll(io.LimitReader(res.Body, 64*1024))
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status %s: %s", res.Status, strings.TrimSpace(string(b)))
+	}
+
+	// receivers are allowed to answer with an empty body, only a decodable one can report rejections
+	resp := &colmetricpb.ExportMetricsServiceResponse{}
+	if err := proto.Unmarshal(b, resp); err != nil {
+		return nil
+	}
+
+	return otlpPartialSuccessError(resp)
+}
+
+func otlpPartialSuccessError(resp *colmetricpb.ExportMetricsServiceResponse) error {
+	ps := resp.GetPartialSuccess()
+	if ps.GetRejectedDataPoints() == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("receiver rejected %d data points: %s", ps.GetRejectedDataPoints(), ps.GetErrorMessage())
+}
@@ -0,0 +1,183 @@
+package output
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+
+	"mikrotik-exporter/internal/config"
+)
+
+func testSnapshot() *Snapshot {
+	counter := dto.MetricType_COUNTER
+	gauge := dto.MetricType_GAUGE
+
+	return &Snapshot{
+		Time: time.Unix(1600000000, 0),
+		Families: []*dto.MetricFamily{
+			{
+				Name: strPtr("mikrotik_interface_rx_byte"),
+				Help: strPtr("rx-byte"),
+				Type: &counter,
+				Metric: []*dto.Metric{
+					{
+						Label:   labelPairs("name", "router1", "address", "10.0.0.1", "interface", "ether1"),
+						Counter: &dto.Counter{Value: floatPtr(1234)},
+					},
+					{
+						Label:   labelPairs("name", "router2", "address", "10.0.0.2", "interface", "ether1"),
+						Counter: &dto.Counter{Value: floatPtr(42)},
+					},
+				},
+			},
+			{
+				Name: strPtr("mikrotik_scrape_collector_success"),
+				Type: &gauge,
+				Metric: []*dto.Metric{
+					{
+						Label: labelPairs("device", "router1"),
+						Gauge: &dto.Gauge{Value: floatPtr(1)},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestOTLPRequest(t *testing.T) {
+	s := &otlpSink{start: time.Unix(1500000000, 0)}
+	req := s.request(testSnapshot())
+
+	if !assert.Len(t, req.ResourceMetrics, 2) {
+		return
+	}
+
+	r1 := req.ResourceMetrics[0]
+	assert.Equal(t, map[string]string{"service.name": "mikrotik-exporter", "device": "router1", "address": "10.0.0.1"}, otlpAttributeMap(r1.Resource.Attributes))
+	assert.Len(t, r1.ScopeMetrics[0].Metrics, 2)
+
+	rx := r1.ScopeMetrics[0].Metrics[0]
+	assert.Equal(t, "mikrotik_interface_rx_byte", rx.Name)
+	assert.True(t, rx.GetSum().IsMonotonic)
+	assert.Equal(t, 1234.0, rx.GetSum().DataPoints[0].GetAsDouble())
+	assert.Equal(t, uint64(1500000000)*uint64(time.Second), rx.GetSum().DataPoints[0].StartTimeUnixNano)
+	assert.Equal(t, uint64(1600000000)*uint64(time.Second), rx.GetSum().DataPoints[0].TimeUnixNano)
+	assert.Equal(t, map[string]string{"interface": "ether1"}, otlpAttributeMap(rx.GetSum().DataPoints[0].Attributes))
+
+	success := r1.ScopeMetrics[0].Metrics[1]
+	assert.Equal(t, 1.0, success.GetGauge().DataPoints[0].GetAsDouble())
+
+	r2 := req.ResourceMetrics[1]
+	assert.Equal(t, "router2", otlpAttributeMap(r2.Resource.Attributes)["device"])
+	assert.Equal(t, 42.0, r2.ScopeMetrics[0].Metrics[0].GetSum().DataPoints[0].GetAsDouble())
+}
+
+func TestOTLPHTTP(t *testing.T) {
+	received := make(chan *colmetricpb.ExportMetricsServiceRequest, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/metrics", r.URL.Path)
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+
+		b, _ := ioutil.ReadAll(r.Body)
+		req := &colmetricpb.ExportMetricsServiceRequest{}
+		assert.NoError(t, proto.Unmarshal(b, req))
+		received <- req
+	}))
+	defer srv.Close()
+
+	s, err := newOTLPSink(&config.Outputs{OTLP: &config.OTLP{
+		Endpoint: srv.URL,
+		Protocol: "http",
+		Headers:  map[string]string{"X-Api-Key": "secret"},
+	}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, s.Write(context.Background(), testSnapshot()))
+	assert.Len(t, (<-received).ResourceMetrics, 2)
+}
+
+func TestOTLPHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	s, err := newOTLPSink(&config.Outputs{OTLP: &config.OTLP{Endpoint: srv.URL, Protocol: "http"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Error(t, s.Write(context.Background(), testSnapshot()))
+}
+
+type otlpReceiver struct {
+	colmetricpb.UnimplementedMetricsServiceServer
+	received chan *colmetricpb.ExportMetricsServiceRequest
+	headers  chan metadata.MD
+}
+
+func (r *otlpReceiver) Export(ctx context.Context, req *colmetricpb.ExportMetricsServiceRequest) (*colmetricpb.ExportMetricsServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	r.headers <- md
+	r.received <- req
+	return &colmetricpb.ExportMetricsServiceResponse{}, nil
+}
+
+func TestOTLPGRPC(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	receiver := &otlpReceiver{
+		received: make(chan *colmetricpb.ExportMetricsServiceRequest, 1),
+		headers:  make(chan metadata.MD, 1),
+	}
+	srv := grpc.NewServer()
+	colmetricpb.RegisterMetricsServiceServer(srv, receiver)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	s, err := newOTLPSink(&config.Outputs{OTLP: &config.OTLP{
+		Endpoint: lis.Addr().String(),
+		Insecure: true,
+		Headers:  map[string]string{"x-api-key": "secret"},
+		Timeout:  5 * time.Second,
+	}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, s.Write(context.Background(), testSnapshot()))
+	assert.Equal(t, []string{"secret"}, (<-receiver.headers).Get("x-api-key"))
+	assert.Len(t, (<-receiver.received).ResourceMetrics, 2)
+}
+
+func TestOTLPUnsupportedProtocol(t *testing.T) {
+	_, err := newOTLPSink(&config.Outputs{OTLP: &config.OTLP{Protocol: "udp"}})
+	assert.Error(t, err)
+}
+
+func otlpAttributeMap(attrs []*commonpb.KeyValue) map[string]string {
+	m := make(map[string]string)
+	for _, a := range attrs {
+		m[a.Key] = a.Value.GetStringValue()
+	}
+
+	return m
+}
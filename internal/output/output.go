@@ -0,0 +1,83 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"mikrotik-exporter/internal/config"
+)
+
+var Registry = &registry{
+	sinks: make(map[string]initialize),
+}
+
+// Snapshot holds the metrics gathered by a single poll of all devices
+type Snapshot struct {
+	Time     time.Time
+	Families []*dto.MetricFamily
+}
+
+// Sink pushes snapshots to an external system
+type Sink interface {
+	Name() string
+	Write(ctx context.Context, s *Snapshot) error
+}
+
+// initialize returns a nil Sink if the output is not configured
+type initialize func(cfg *config.Outputs) (Sink, error)
+
+type registry struct {
+	sinks map[string]initialize
+}
+
+func (r *registry) Add(name string, init initialize) {
+	if _, exists := r.sinks[name]; exists {
+		panic(fmt.Sprintf("already registered of %s", name))
+	}
+
+	r.sinks[name] = init
+}
+
+func (r *registry) Load(cfg *config.Outputs) ([]Sink, error) {
+	names := make([]string, 0, len(r.sinks))
+	for name := range r.sinks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sinks []Sink
+	for _, name := range names {
+		s, err := r.sinks[name](cfg)
+		if err != nil {
+			return nil, fmt.Errorf("could not set up output %s: %w", name, err)
+		}
+
+		if s != nil {
+			sinks = append(sinks, s)
+		}
+	}
+
+	return sinks, nil
+}
+
+// metricValue returns the value of counters, gauges and untyped metrics unless it is NaN or infinite
+func metricValue(t dto.MetricType, m *dto.Metric) (float64, bool) {
+	var v float64
+	switch t {
+	case dto.MetricType_COUNTER:
+		v = m.GetCounter().GetValue()
+	case dto.MetricType_GAUGE:
+		v = m.GetGauge().GetValue()
+	case dto.MetricType_UNTYPED:
+		v = m.GetUntyped().GetValue()
+	default:
+		return 0, false
+	}
+
+	return v, !math.IsNaN(v) && !math.IsInf(v, 0)
+}
@@ -0,0 +1,23 @@
+package output
+
+import (
+	dto "github.com/prometheus/client_model/go"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+// labelPairs builds label pairs from alternating names and values
+func labelPairs(kv ...string) []*dto.LabelPair {
+	labels := make([]*dto.LabelPair, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		labels = append(labels, &dto.LabelPair{Name: strPtr(kv[i]), Value: strPtr(kv[i+1])})
+	}
+
+	return labels
+}
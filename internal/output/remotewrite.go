@@ -0,0 +1,320 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"mikrotik-exporter/internal/config"
+)
+
+func init() {
+	Registry.Add("remote_write", newRemoteWriteSink)
+}
+
+const (
+	remoteWriteDefaultRetries        = 3
+	remoteWriteDefaultRetryBackoff   = time.Second
+	remoteWriteDefaultBufferMaxFiles = 1000
+	remoteWriteBufferExt             = ".snappy"
+)
+
+type remoteWriteLabel struct {
+	name, value string
+}
+
+type remoteWriteSink struct {
+	sync.Mutex
+	client         *http.Client
+	url            string
+	basicAuth      *config.BasicAuth
+	bearerToken    string
+	headers        map[string]string
+	externalLabels []remoteWriteLabel
+	retries        int
+	retryBackoff   time.Duration
+	timeout        time.Duration
+	bufferDir      string
+	bufferMaxFiles int
+}
+
+func newRemoteWriteSink(cfg *config.Outputs) (Sink, error) {
+	c := cfg.RemoteWrite
+	if c == nil {
+		return nil, nil
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("url %q is not valid", c.URL)
+	}
+
+	if c.BufferDir != "" {
+		if err := os.MkdirAll(c.BufferDir, 0o750); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &remoteWriteSink{
+		client: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: c.Insecure},
+			},
+		},
+		url:            c.URL,
+		basicAuth:      c.BasicAuth,
+		bearerToken:    c.BearerToken,
+		headers:        c.Headers,
+		retries:        c.Retries,
+		retryBackoff:   c.RetryBackoff,
+		timeout:        c.Timeout,
+		bufferDir:      c.BufferDir,
+		bufferMaxFiles: c.BufferMaxFiles,
+	}
+	for name, value := range c.ExternalLabels {
+		// remote_write does not allow empty label values
+		if value == "" {
+			continue
+		}
+		s.externalLabels = append(s.externalLabels, remoteWriteLabel{name, value})
+	}
+	if s.retries < 0 {
+		s.retries = 0
+	} else if s.retries == 0 {
+		s.retries = remoteWriteDefaultRetries
+	}
+	if s.retryBackoff <= 0 {
+		s.retryBackoff = remoteWriteDefaultRetryBackoff
+	}
+	if s.bufferMaxFiles <= 0 {
+		s.bufferMaxFiles = remoteWriteDefaultBufferMaxFiles
+	}
+
+	return s, nil
+}
+
+func (s *remoteWriteSink) Name() string {
+	return "remote_write"
+}
+
+// Write sends the snapshot after all buffered requests have been delivered, so samples arrive in order.
+// Requests which cannot be delivered due to an outage are buffered to disk if a buffer dir is configured.
+func (s *remoteWriteSink) Write(ctx context.Context, snap *Snapshot) error {
+	s.Lock()
+	defer s.Unlock()
+
+	body := snappy.Encode(nil, remoteWriteRequest(snap, s.externalLabels))
+
+	err := s.flushBuffer(ctx)
+	if err == nil {
+		err = retry(ctx, s.Name(), s.retries, s.retryBackoff, func() error {
+			return s.post(ctx, body)
+		})
+	}
+
+	var se *statusError
+	if err == nil || s.bufferDir == "" || (errors.As(err, &se) && !se.recoverable()) {
+		return err
+	}
+
+	if berr := s.buffer(body); berr != nil {
+		return fmt.Errorf("could not buffer request after %v: %w", err, berr)
+	}
+
+	return fmt.Errorf("request buffered: %w", err)
+}
+
+func (s *remoteWriteSink) post(ctx context.Context, body []byte) error {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range s.headers {
+		r.Header.Set(k, v)
+	}
+	r.Header.Set("Content-Encoding", "snappy")
+	r.Header.Set("Content-Type", "application/x-protobuf")
+	r.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if s.basicAuth != nil {
+		r.SetBasicAuth(s.basicAuth.Username, s.basicAuth.Password)
+	} else if s.bearerToken != "" {
+		r.Header.Set("Authorization", "Bearer "+s.bearerToken)
+	}
+
+	res, err := s.client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return checkResponse(res)
+}
+
+// bufferedFiles returns the buffered requests, oldest first
+func (s *remoteWriteSink) bufferedFiles() ([]string, error) {
+	if s.bufferDir == "" {
+		return nil, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(s.bufferDir, "*"+remoteWriteBufferExt))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+func (s *remoteWriteSink) buffer(body []byte) error {
+	name := filepath.Join(s.bufferDir, fmt.Sprintf("%020d%s", time.Now().UnixNano(), remoteWriteBufferExt))
+	if err := ioutil.WriteFile(name+".tmp", body, 0o640); err != nil {
+		return err
+	}
+	if err := os.Rename(name+".tmp", name); err != nil {
+		return err
+	}
+
+	files, err := s.bufferedFiles()
+	if err != nil {
+		return err
+	}
+
+	for len(files) > s.bufferMaxFiles {
+		log.WithField("file", files[0]).Warn("remote_write buffer full, dropping oldest request")
+		if err := os.Remove(files[0]); err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+
+	return nil
+}
+
+// flushBuffer sends the buffered requests oldest first and stops at the first recoverable error
+func (s *remoteWriteSink) flushBuffer(ctx context.Context) error {
+	files, err := s.bufferedFiles()
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		body, err := ioutil.ReadFile(f)
+		if err != nil {
+			return err
+		}
+
+		err = s.post(ctx, body)
+
+		var se *statusError
+		if errors.As(err, &se) && !se.recoverable() {
+			log.WithFields(log.Fields{
+				"file":  f,
+				"error": err,
+			}).Error("remote_write rejected buffered request, dropping it")
+		} else if err != nil {
+			return err
+		}
+
+		if err := os.Remove(f); err != nil {
+			return err
+		}
+	}
+
+	if len(files) > 0 {
+		log.WithField("count", len(files)).Info("flushed buffered remote_write requests")
+	}
+
+	return nil
+}
+
+// remoteWriteRequest encodes the snapshot as prometheus.WriteRequest protobuf message
+func remoteWriteRequest(snap *Snapshot, externalLabels []remoteWriteLabel) []byte {
+	var (
+		b  []byte
+		ts = snap.Time.UnixNano() / int64(time.Millisecond)
+	)
+
+	for _, mf := range snap.Families {
+		for _, m := range mf.GetMetric() {
+			v, ok := metricValue(mf.GetType(), m)
+			if !ok {
+				continue
+			}
+
+			// empty label values are not allowed, Prometheus treats them as missing labels
+			labels := []remoteWriteLabel{{"__name__", mf.GetName()}}
+			names := make(map[string]bool)
+			for _, l := range m.GetLabel() {
+				if l.GetValue() == "" {
+					continue
+				}
+				labels = append(labels, remoteWriteLabel{l.GetName(), l.GetValue()})
+				names[l.GetName()] = true
+			}
+			for _, el := range externalLabels {
+				if !names[el.name] {
+					labels = append(labels, el)
+				}
+			}
+			sort.Slice(labels, func(i, j int) bool {
+				return strings.Compare(labels[i].name, labels[j].name) < 0
+			})
+
+			b = protowire.AppendTag(b, 1, protowire.BytesType)
+			b = protowire.AppendBytes(b, remoteWriteTimeSeries(labels, v, ts))
+		}
+	}
+
+	return b
+}
+
+func remoteWriteTimeSeries(labels []remoteWriteLabel, v float64, ts int64) []byte {
+	var b []byte
+
+	for _, l := range labels {
+		var lb []byte
+		lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+		lb = protowire.AppendString(lb, l.name)
+		lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+		lb = protowire.AppendString(lb, l.value)
+
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, lb)
+	}
+
+	var sb []byte
+	sb = protowire.AppendTag(sb, 1, protowire.Fixed64Type)
+	sb = protowire.AppendFixed64(sb, math.Float64bits(v))
+	sb = protowire.AppendTag(sb, 2, protowire.VarintType)
+	sb = protowire.AppendVarint(sb, uint64(ts))
+
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	b = protowire.AppendBytes(b, sb)
+
+	return b
+}
@@ -0,0 +1,220 @@
+package output
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"mikrotik-exporter/internal/config"
+)
+
+type testSeries struct {
+	labels    map[string]string
+	value     float64
+	timestamp int64
+}
+
+// decodeWriteRequest decodes the subset of prometheus.WriteRequest written by the sink
+func decodeWriteRequest(t *testing.T, compressed []byte) []testSeries {
+	b, err := snappy.Decode(nil, compressed)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+
+	var series []testSeries
+	forEachField(t, b, func(_ protowire.Number, v []byte, _ uint64) {
+		ts := testSeries{labels: make(map[string]string)}
+		forEachField(t, v, func(num protowire.Number, v []byte, _ uint64) {
+			switch num {
+			case 1:
+				var name, value string
+				forEachField(t, v, func(num protowire.Number, v []byte, _ uint64) {
+					if num == 1 {
+						name = string(v)
+					} else {
+						value = string(v)
+					}
+				})
+				ts.labels[name] = value
+			case 2:
+				forEachField(t, v, func(num protowire.Number, _ []byte, n uint64) {
+					if num == 1 {
+						ts.value = math.Float64frombits(n)
+					} else {
+						ts.timestamp = int64(n)
+					}
+				})
+			}
+		})
+		series = append(series, ts)
+	})
+
+	return series
+}
+
+// forEachField calls fn with the payload of length delimited fields or the value of numeric fields
+func forEachField(t *testing.T, b []byte, fn func(num protowire.Number, v []byte, n uint64)) {
+	for len(b) > 0 {
+		num, typ, l := protowire.ConsumeTag(b)
+		if !assert.True(t, l > 0) {
+			return
+		}
+		b = b[l:]
+
+		var (
+			v []byte
+			n uint64
+		)
+		switch typ {
+		case protowire.BytesType:
+			v, l = protowire.ConsumeBytes(b)
+		case protowire.Fixed64Type:
+			n, l = protowire.ConsumeFixed64(b)
+		case protowire.VarintType:
+			n, l = protowire.ConsumeVarint(b)
+		default:
+			t.Fatalf("unexpected wire type %v", typ)
+		}
+		if !assert.True(t, l > 0) {
+			return
+		}
+		b = b[l:]
+
+		fn(num, v, n)
+	}
+}
+
+type remoteWriteReceiver struct {
+	sync.Mutex
+	status  int
+	bodies  [][]byte
+	headers []http.Header
+}
+
+func (r *remoteWriteReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.status != 0 {
+		http.Error(w, "unavailable", r.status)
+		return
+	}
+
+	b, _ := ioutil.ReadAll(req.Body)
+	r.bodies = append(r.bodies, b)
+	r.headers = append(r.headers, req.Header)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestRemoteWrite(t *testing.T) {
+	receiver := &remoteWriteReceiver{}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	s, err := newRemoteWriteSink(&config.Outputs{RemoteWrite: &config.RemoteWrite{
+		URL:            srv.URL,
+		BasicAuth:      &config.BasicAuth{Username: "user", Password: "pass"},
+		ExternalLabels: map[string]string{"site": "branch1"},
+	}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, s.Write(context.Background(), testSnapshot()))
+	if !assert.Len(t, receiver.bodies, 1) {
+		return
+	}
+
+	h := receiver.headers[0]
+	assert.Equal(t, "snappy", h.Get("Content-Encoding"))
+	assert.Equal(t, "application/x-protobuf", h.Get("Content-Type"))
+	assert.Equal(t, "0.1.0", h.Get("X-Prometheus-Remote-Write-Version"))
+	assert.Equal(t, "Basic dXNlcjpwYXNz", h.Get("Authorization"))
+
+	series := decodeWriteRequest(t, receiver.bodies[0])
+	if assert.Len(t, series, 3) {
+		assert.Equal(t, map[string]string{
+			"__name__":  "mikrotik_interface_rx_byte",
+			"name":      "router1",
+			"address":   "10.0.0.1",
+			"interface": "ether1",
+			"site":      "branch1",
+		}, series[0].labels)
+		assert.Equal(t, 1234.0, series[0].value)
+		assert.Equal(t, int64(1600000000000), series[0].timestamp)
+	}
+}
+
+func TestRemoteWriteRequestEmptyLabels(t *testing.T) {
+	gauge := dto.MetricType_GAUGE
+	snap := &Snapshot{
+		Time: time.Unix(1600000000, 0),
+		Families: []*dto.MetricFamily{
+			{
+				Name: strPtr("mikrotik_firewall_rule_bytes"),
+				Type: &gauge,
+				Metric: []*dto.Metric{
+					{
+						Label: labelPairs("name", "router1", "comment", "", "site", ""),
+						Gauge: &dto.Gauge{Value: floatPtr(1)},
+					},
+				},
+			},
+		},
+	}
+
+	b := remoteWriteRequest(snap, []remoteWriteLabel{{"site", "branch1"}})
+
+	series := decodeWriteRequest(t, snappy.Encode(nil, b))
+	if assert.Len(t, series, 1) {
+		assert.Equal(t, map[string]string{
+			"__name__": "mikrotik_firewall_rule_bytes",
+			"name":     "router1",
+			"site":     "branch1",
+		}, series[0].labels)
+	}
+}
+
+func TestRemoteWriteBuffer(t *testing.T) {
+	receiver := &remoteWriteReceiver{status: http.StatusServiceUnavailable}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	s, err := newRemoteWriteSink(&config.Outputs{RemoteWrite: &config.RemoteWrite{
+		URL:            srv.URL,
+		BearerToken:    "secret",
+		Retries:        -1,
+		BufferDir:      dir,
+		BufferMaxFiles: 2,
+	}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for i := 0; i < 3; i++ {
+		assert.Error(t, s.Write(context.Background(), testSnapshot()))
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	assert.Len(t, files, 2)
+
+	receiver.status = 0
+	assert.NoError(t, s.Write(context.Background(), testSnapshot()))
+	assert.Len(t, receiver.bodies, 3)
+	assert.Equal(t, "Bearer secret", receiver.headers[0].Get("Authorization"))
+
+	files, _ = filepath.Glob(filepath.Join(dir, "*"))
+	assert.Len(t, files, 0)
+}
@@ -0,0 +1,76 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// statusError is returned for requests answered with a non-2xx status
+type statusError struct {
+	code int
+	body string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.code, e.body)
+}
+
+// recoverable reports whether sending the same request again could succeed
+func (e *statusError) recoverable() bool {
+	return e.code >= 500 || e.code == http.StatusTooManyRequests
+}
+
+// checkResponse drains the response and returns a statusError for non-2xx responses
+func checkResponse(res *http.Response) error {
+	b, _ := ioutil.ReadAll(io.LimitReader(res.Body, 64*1024))
+
+	if res.StatusCode/100 != 2 {
+		return &statusError{code: res.StatusCode, body: strings.TrimSpace(string(b))}
+	}
+
+	return nil
+}
+
+// retry calls fn until it succeeds, fails unrecoverably or has been retried retries times,
+// doubling the backoff after every attempt
+func retry(ctx context.Context, name string, retries int, backoff time.Duration, fn func() error) error {
+	var err error
+
+	for attempt := 0; ; attempt++ {
+		err = fn()
+		if err == nil {
+			return nil
+		}
+
+		var se *statusError
+		if errors.As(err, &se) && !se.recoverable() {
+			return err
+		}
+
+		if attempt >= retries {
+			return err
+		}
+
+		log.WithFields(log.Fields{
+			"output":  name,
+			"attempt": attempt + 1,
+			"backoff": backoff,
+			"error":   err,
+		}).Warn("error writing to output, retrying")
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
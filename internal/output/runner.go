@@ -0,0 +1,87 @@
+package output
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultInterval is used when no poll interval is configured
+const DefaultInterval = 30 * time.Second
+
+// Runner polls the devices on an interval and hands the results to the sinks
+type Runner struct {
+	gatherer prometheus.Gatherer
+	interval time.Duration
+	sinks    []Sink
+}
+
+// NewRunner creates a runner gathering from g every interval
+func NewRunner(g prometheus.Gatherer, interval time.Duration, sinks ...Sink) *Runner {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &Runner{
+		gatherer: g,
+		interval: interval,
+		sinks:    sinks,
+	}
+}
+
+// Run polls until ctx is cancelled
+func (r *Runner) Run(ctx context.Context) {
+	log.WithFields(log.Fields{
+		"interval": r.interval,
+		"numSinks": len(r.sinks),
+	}).Info("starting push outputs")
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Runner) poll(ctx context.Context) {
+	now := time.Now()
+
+	mfs, err := r.gatherer.Gather()
+	if err != nil {
+		// the registry returns whatever it could gather alongside the error
+		log.WithField("error", err).Error("error gathering metrics for outputs")
+	}
+
+	snap := &Snapshot{Time: now, Families: mfs}
+
+	ctx, cancel := context.WithTimeout(ctx, r.interval)
+	defer cancel()
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(r.sinks))
+
+	for _, s := range r.sinks {
+		go func(s Sink) {
+			defer wg.Done()
+
+			if err := s.Write(ctx, snap); err != nil {
+				log.WithFields(log.Fields{
+					"output": s.Name(),
+					"error":  err,
+				}).Error("error writing to output")
+			}
+		}(s)
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,190 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"mikrotik-exporter/internal/config"
+)
+
+const (
+	webhookDefaultRetries      = 3
+	webhookDefaultRetryBackoff = time.Second
+	webhookDefaultTimeout      = 10 * time.Second
+	webhookQueueSize           = 1000
+)
+
+// StateChange is the payload sent to the webhook when the state of an object changed between scrapes
+type StateChange struct {
+	Device    string    `json:"device"`
+	Kind      string    `json:"kind"`
+	Object    string    `json:"object"`
+	OldState  string    `json:"old_state"`
+	NewState  string    `json:"new_state"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type stateKey struct {
+	device, kind, object string
+}
+
+// Webhook remembers the last known state of objects and posts state changes to an HTTP endpoint
+type Webhook struct {
+	sync.Mutex
+	states       map[stateKey]string
+	kinds        map[string]bool
+	queue        chan *StateChange
+	client       *http.Client
+	url          string
+	basicAuth    *config.BasicAuth
+	bearerToken  string
+	headers      map[string]string
+	retries      int
+	retryBackoff time.Duration
+	timeout      time.Duration
+}
+
+// NewWebhook creates a webhook and starts delivering state changes in the background
+func NewWebhook(cfg *config.Webhook) (*Webhook, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("url %q is not valid", cfg.URL)
+	}
+
+	w := &Webhook{
+		states: make(map[stateKey]string),
+		queue:  make(chan *StateChange, webhookQueueSize),
+		client: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: cfg.Insecure},
+			},
+		},
+		url:          cfg.URL,
+		basicAuth:    cfg.BasicAuth,
+		bearerToken:  cfg.BearerToken,
+		headers:      cfg.Headers,
+		retries:      cfg.Retries,
+		retryBackoff: cfg.RetryBackoff,
+		timeout:      cfg.Timeout,
+	}
+	if len(cfg.Kinds) > 0 {
+		w.kinds = make(map[string]bool)
+		for _, k := range cfg.Kinds {
+			w.kinds[k] = true
+		}
+	}
+	if w.retries < 0 {
+		w.retries = 0
+	} else if w.retries == 0 {
+		w.retries = webhookDefaultRetries
+	}
+	if w.retryBackoff <= 0 {
+		w.retryBackoff = webhookDefaultRetryBackoff
+	}
+	if w.timeout <= 0 {
+		w.timeout = webhookDefaultTimeout
+	}
+
+	go w.deliver()
+
+	return w, nil
+}
+
+// ObserveState implements the collector.StateObserver interface.
+// The first state seen for an object is only remembered, every change after that is sent.
+func (w *Webhook) ObserveState(device, kind, object, state string) {
+	if state == "" || (w.kinds != nil && !w.kinds[kind]) {
+		return
+	}
+
+	key := stateKey{device, kind, object}
+
+	w.Lock()
+	old, known := w.states[key]
+	w.states[key] = state
+	w.Unlock()
+
+	if !known || old == state {
+		return
+	}
+
+	change := &StateChange{
+		Device:    device,
+		Kind:      kind,
+		Object:    object,
+		OldState:  old,
+		NewState:  state,
+		Timestamp: time.Now(),
+	}
+
+	select {
+	case w.queue <- change:
+	default:
+		log.WithFields(log.Fields{
+			"device": device,
+			"kind":   kind,
+			"object": object,
+		}).Warn("webhook queue full, dropping state change")
+	}
+}
+
+func (w *Webhook) deliver() {
+	for change := range w.queue {
+		ctx := context.Background()
+		err := retry(ctx, "webhook", w.retries, w.retryBackoff, func() error {
+			return w.post(ctx, change)
+		})
+		if err != nil {
+			log.WithFields(log.Fields{
+				"device": change.Device,
+				"kind":   change.Kind,
+				"object": change.Object,
+				"error":  err,
+			}).Error("error sending state change to webhook")
+		}
+	}
+}
+
+func (w *Webhook) post(ctx context.Context, change *StateChange) error {
+	body, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range w.headers {
+		r.Header.Set(k, v)
+	}
+	r.Header.Set("Content-Type", "application/json")
+	if w.basicAuth != nil {
+		r.SetBasicAuth(w.basicAuth.Username, w.basicAuth.Password)
+	} else if w.bearerToken != "" {
+		r.Header.Set("Authorization", "Bearer "+w.bearerToken)
+	}
+
+	res, err := w.client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return checkResponse(res)
+}
@@ -0,0 +1,65 @@
+package output
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"mikrotik-exporter/internal/config"
+)
+
+func TestWebhook(t *testing.T) {
+	received := make(chan StateChange, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		change := StateChange{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&change))
+		received <- change
+	}))
+	defer srv.Close()
+
+	w, err := NewWebhook(&config.Webhook{
+		URL:         srv.URL,
+		BearerToken: "secret",
+		Kinds:       []string{"bgp", "netwatch"},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	w.ObserveState("router1", "bgp", "peer1", "established")
+	w.ObserveState("router1", "bgp", "peer1", "established")
+	w.ObserveState("router2", "bgp", "peer1", "active")
+	w.ObserveState("router1", "interface", "ether1", "up")
+	w.ObserveState("router1", "interface", "ether1", "down")
+	w.ObserveState("router1", "bgp", "peer1", "")
+	w.ObserveState("router1", "bgp", "peer1", "active")
+	w.ObserveState("router1", "netwatch", "10.0.0.1", "up")
+	w.ObserveState("router1", "netwatch", "10.0.0.1", "down")
+
+	for _, expected := range []StateChange{
+		{Device: "router1", Kind: "bgp", Object: "peer1", OldState: "established", NewState: "active"},
+		{Device: "router1", Kind: "netwatch", Object: "10.0.0.1", OldState: "up", NewState: "down"},
+	} {
+		select {
+		case change := <-received:
+			assert.False(t, change.Timestamp.IsZero())
+			change.Timestamp = time.Time{}
+			assert.Equal(t, expected, change)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for webhook")
+		}
+	}
+
+	select {
+	case change := <-received:
+		t.Fatalf("unexpected state change %+v", change)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -18,6 +19,7 @@ import (
 	"mikrotik-exporter/internal/collector"
 	"mikrotik-exporter/internal/config"
 	"mikrotik-exporter/internal/metrics"
+	"mikrotik-exporter/internal/output"
 )
 
 // single device can be defined via CLI flags, multiple via config file.
@@ -126,11 +128,20 @@ func loadConfigFromFlags() (*config.Config, error) {
 }
 
 func startServer() {
-	h, err := createMetricsHandler()
+	registry, err := createRegistry()
 	if err != nil {
 		log.Fatal(err)
 	}
-	http.Handle(*metricsPath, h)
+
+	if err := startOutputs(registry); err != nil {
+		log.Fatal(err)
+	}
+
+	http.Handle(*metricsPath, promhttp.HandlerFor(registry,
+		promhttp.HandlerOpts{
+			ErrorLog:      log.New(),
+			ErrorHandling: promhttp.ContinueOnError,
+		}))
 
 	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		_, _ = w.Write([]byte("ok"))
@@ -150,8 +161,8 @@ func startServer() {
 	log.Fatal(http.ListenAndServe(*listen, nil))
 }
 
-func createMetricsHandler() (http.Handler, error) {
-	feats, err := metrics.Registry.Load(strings.Split(*features, ",")...)
+func createRegistry() (*prometheus.Registry, error) {
+	feats, err := metrics.Registry.Load(&cfg.Collectors, strings.Split(*features, ",")...)
 	if err != nil {
 		return nil, err
 	}
@@ -165,6 +176,14 @@ func createMetricsHandler() (http.Handler, error) {
 		opts = append(opts, collector.WithTLS(*insecure))
 	}
 
+	if cfg.Outputs.Webhook != nil {
+		w, err := output.NewWebhook(cfg.Outputs.Webhook)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, collector.WithStateObserver(w))
+	}
+
 	nc, err := collector.NewCollector(cfg, opts...)
 	if err != nil {
 		return nil, err
@@ -175,9 +194,21 @@ func createMetricsHandler() (http.Handler, error) {
 		return nil, err
 	}
 
-	return promhttp.HandlerFor(registry,
-		promhttp.HandlerOpts{
-			ErrorLog:      log.New(),
-			ErrorHandling: promhttp.ContinueOnError,
-		}), nil
+	return registry, nil
+}
+
+func startOutputs(g prometheus.Gatherer) error {
+	sinks, err := output.Registry.Load(&cfg.Outputs)
+	if err != nil {
+		return err
+	}
+
+	// the webhook is notified by the scrapes themselves and needs no runner
+	if len(sinks) == 0 {
+		return nil
+	}
+
+	go output.NewRunner(g, cfg.Outputs.Interval, sinks...).Run(context.Background())
+
+	return nil
 }